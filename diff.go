@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	mysql "github.com/lights-T/mysql_generate/model"
+)
+
+// Diff runs the diff subcommand and returns the exit code, 0 when the schemas are the same,
+// 1 when they differ and 2 on errors
+func Diff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	table := fs.String("t", "", "compare only this table,like t_user")
+	alter := fs.String("alter", "", "write the statements turning the target into the source to this file, - for stdout")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `
+Usage: NewModel diff [-t table] [-alter file] source target
+
+source and target are each a DSN like user:password@tcp(127.0.0.1:3306)/d_user or a .sql file,
+the report lists what the target misses or has extra compared with the source.
+
+Options:
+`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	var schemas [2][]*mysql.CreateTable
+	for i, target := range fs.Args() {
+		source, database, err := mysql.OpenSchema(target)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		schemas[i] = mysql.LoadTables(source, database, *table)
+	}
+	d := mysql.DiffSchemas(schemas[0], schemas[1])
+
+	// the report goes to stderr when stdout carries the script
+	report := os.Stdout
+	if *alter == "-" {
+		report = os.Stderr
+	}
+	if d.IsEmpty() {
+		fmt.Fprintln(report, "no difference")
+		return 0
+	}
+	fmt.Fprint(report, d.Report())
+
+	if len(*alter) > 0 {
+		script := strings.Join(d.Alter(), "\n") + "\n"
+		if *alter == "-" {
+			fmt.Print(script)
+		} else if err := ioutil.WriteFile(*alter, []byte(script), 0644); err != nil {
+			fmt.Fprintln(os.Stderr, "write alter script err:", err)
+			return 2
+		}
+	}
+	return 1
+}
@@ -19,17 +19,41 @@ var (
 	V        bool
 	v        bool
 	env      string
+	file     string
+	null     string
+	tinyBool bool
+	decimal  string
+	timeType bool
+	jsonType string
+	confFile string
+	audit    string
+	softDel  string
+	tmplDir  string
 )
 
 const CurrentVersion = "1.0.3"
 
 //go:generate mysql_generate -a love_house:ebXRwXwGPAhHM6S6@tcp(192.168.1.8:3306) -d love_house -t lv_bill_detail -e windows
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		os.Exit(Diff(os.Args[2:]))
+	}
+
 	flag.BoolVar(&help, "help", false, "get help")
 	flag.StringVar(&addr, "a", "", "mysql connection address,like a user:password@tcp(127.0.0.1)")
 	flag.StringVar(&database, "d", "", "mysql database name,like d_user")
 	flag.StringVar(&table, "t", "", "mysql table name,like t_user")
 	flag.StringVar(&env, "e", "linux", "env name,like windows or linux")
+	flag.StringVar(&null, "null", "", "nullable column type,pointer for *T or sql for sql.NullString etc, empty keeps plain types")
+	flag.BoolVar(&tinyBool, "bool", false, "map tinyint(1) columns to bool")
+	flag.StringVar(&decimal, "decimal", "float", "decimal column type,float, string, shopspring for decimal.Decimal or local for modelutil.Decimal")
+	flag.BoolVar(&timeType, "time", false, "map datetime, timestamp and date columns to time.Time, parseTime=true is added to the connection")
+	flag.StringVar(&jsonType, "json", "", "json column type,like map[string]interface{} or github.com/x/types.JSON, default json.RawMessage")
+	flag.StringVar(&confFile, "c", "", "config file in JSON,overrides the go type per table.column or per sql type")
+	flag.StringVar(&audit, "audit", "", "audit columns skipped on insert and update,like created_at,gmt_modified, default create_time,update_time")
+	flag.StringVar(&softDel, "soft-delete", "", "soft delete columns,the first one a table has is used, default deleted_at,is_deleted, - disables")
+	flag.StringVar(&tmplDir, "template-dir", "", "directory of .tmpl files,a file named like a built-in template replaces it, the others are rendered after them")
+	flag.StringVar(&file, "f", "", "schema file with CREATE TABLE statements,like schema.sql, generate without a mysql connection")
 
 	flag.BoolVar(&v, "v", false, "get version")
 	flag.BoolVar(&V, "V", false, "get version")
@@ -46,7 +70,19 @@ func main() {
 		os.Exit(0)
 	}
 
-	if len(addr) == 0 || len(database) == 0 {
+	if null != "" && null != mysql.NullPointer && null != mysql.NullSQL {
+		fmt.Fprintf(os.Stderr, "invalid -null value [%s], must be pointer or sql\n", null)
+		os.Exit(1)
+	}
+
+	switch decimal {
+	case mysql.DecimalFloat, mysql.DecimalString, mysql.DecimalShopspring, mysql.DecimalLocal:
+	default:
+		fmt.Fprintf(os.Stderr, "invalid -decimal value [%s], must be float, string, shopspring or local\n", decimal)
+		os.Exit(1)
+	}
+
+	if len(file) == 0 && (len(addr) == 0 || len(database) == 0) {
 		a, ok := os.LookupEnv("DATABASE_URL")
 		if ok {
 			database = strings.TrimRight(strings.TrimLeft(a, "/"), "?")
@@ -56,14 +92,27 @@ func main() {
 			return
 		}
 	}
-	mysql.SaveConfig(addr, database, table, env)
+	mysql.SaveConfig(addr, database, table, env, file)
+	var auditColumns []string
+	if len(audit) > 0 {
+		auditColumns = strings.Split(audit, ",")
+	}
+	var softDeleteColumns []string
+	if softDel == "-" {
+		softDeleteColumns = []string{}
+	} else if len(softDel) > 0 {
+		softDeleteColumns = strings.Split(softDel, ",")
+	}
+	mysql.SaveOptions(&mysql.Options{Null: null, TinyIntBool: tinyBool, Decimal: decimal, Time: timeType, JSONType: jsonType, ConfigFile: confFile, AuditColumns: auditColumns,
+		SoftDeleteColumns: softDeleteColumns, TemplateDir: tmplDir})
 	mysql.Init()
 }
 
 func Usage() {
 	fmt.Fprintf(os.Stderr, `
 Generation Version: %s
-Usage: NewModel [-adt] [-a address] [-d database] [-t table ]
+Usage: NewModel [-adtf] [-a address] [-d database] [-t table ] [-f schema.sql] [-null pointer|sql] [-bool] [-decimal float|string|shopspring|local] [-time] [-json type] [-c config.json] [-audit columns] [-soft-delete columns] [-template-dir dir]
+       NewModel diff [-t table] [-alter file] source target
 
 Options:
 `, CurrentVersion)
@@ -0,0 +1,75 @@
+package mysql
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	gotoken "go/token"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// accessorFile is written to the model directory when its package has no GetInstance
+const accessorFile = "db.go"
+
+// WriteAccessor writes db.go, the GetInstance the models call, into the current model
+// directory unless the package declares GetInstance already
+func WriteAccessor() error {
+	name, found, err := scanModelPackage(".")
+	if err != nil {
+		return err
+	}
+	if found {
+		return nil
+	}
+	if _, err := os.Stat(accessorFile); err == nil {
+		return fmt.Errorf("%s exists without GetInstance, remove it or declare GetInstance", accessorFile)
+	}
+	if templates == nil {
+		if err := LoadTemplates(""); err != nil {
+			return err
+		}
+	}
+	var b bytes.Buffer
+	if err := templates.ExecuteTemplate(&b, "db.tmpl", struct{ Package string }{name}); err != nil {
+		return err
+	}
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(accessorFile, src, 0644); err != nil {
+		return err
+	}
+	fmt.Println("Create [" + accessorFile + "] Success")
+	return nil
+}
+
+// scanModelPackage returns the package name of the go files in dir and whether one of
+// them declares the GetInstance function
+func scanModelPackage(dir string) (string, bool, error) {
+	pkgs, err := parser.ParseDir(gotoken.NewFileSet(), dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return "", false, err
+	}
+	name := ""
+	for n, pkg := range pkgs {
+		name = n
+		for _, f := range pkg.Files {
+			for _, d := range f.Decls {
+				if fn, ok := d.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "GetInstance" {
+					return n, true, nil
+				}
+			}
+		}
+	}
+	if len(name) == 0 {
+		return "", false, fmt.Errorf("no go file in the model dir")
+	}
+	return name, false, nil
+}
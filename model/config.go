@@ -0,0 +1,116 @@
+package mysql
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+var config = &Config{}
+
+// Config is the -c config file, it is JSON like
+//
+//	{
+//	  "columns": {"t_user.balance": {"type": "decimal.Decimal", "import": "github.com/shopspring/decimal"}},
+//	  "types": {"tinyint(1)": {"type": "bool"}, "json": {"type": "github.com/x/types.JSON", "nullable": true}},
+//	  "audit_columns": ["created_at", "gmt_modified"],
+//	  "soft_delete_columns": ["deleted_at"],
+//	  "renames": {"t_user.nick": "nickname"}
+//	}
+type Config struct {
+	Columns           map[string]*TypeOverride `json:"columns"`             // table.column ==> type
+	Types             map[string]*TypeOverride `json:"types"`               // sql type, like decimal, decimal(10,2) or bigint unsigned ==> type
+	AuditColumns      []string                 `json:"audit_columns"`       // columns skipped on insert and update, default create_time and update_time
+	SoftDeleteColumns []string                 `json:"soft_delete_columns"` // columns marking deleted rows, default deleted_at and is_deleted, [] disables
+	Renames           map[string]string        `json:"renames"`             // table.old_column ==> new column, for the migrations
+}
+
+// TypeOverride replaces the go type ConvertType would generate
+type TypeOverride struct {
+	Type     string `json:"type"`     // go type, like decimal.Decimal or github.com/x/types.JSON
+	Import   string `json:"import"`   // import path of the type when Type has no path
+	Nullable bool   `json:"nullable"` // the type handles NULL itself, nullable columns are not wrapped
+}
+
+// LoadConfig reads the config file and registers the imports of its types
+func LoadConfig(file string) error {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	c := &Config{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return fmt.Errorf("parse config [%s] err: %v", file, err)
+	}
+	columns := make(map[string]*TypeOverride, len(c.Columns))
+	for k, o := range c.Columns {
+		if err := o.register(k); err != nil {
+			return err
+		}
+		columns[strings.ToLower(k)] = o
+	}
+	types := make(map[string]*TypeOverride, len(c.Types))
+	for k, o := range c.Types {
+		if err := o.register(k); err != nil {
+			return err
+		}
+		types[strings.ToLower(strings.Join(strings.Fields(k), " "))] = o
+	}
+	renames := make(map[string]string, len(c.Renames))
+	for k, v := range c.Renames {
+		renames[strings.ToLower(k)] = v
+	}
+	c.Columns, c.Types, c.Renames = columns, types, renames
+	config = c
+	return nil
+}
+
+func (o *TypeOverride) register(key string) error {
+	if o == nil || len(o.Type) == 0 {
+		return fmt.Errorf("config [%s] has no type", key)
+	}
+	o.Type = registerType(o.Type)
+	if len(o.Import) > 0 {
+		qualifier := strings.TrimLeft(o.Type, "*[]")
+		if i := strings.Index(qualifier, "."); i > 0 {
+			typeImports[qualifier[:i]] = o.Import
+		}
+	}
+	if o.Nullable {
+		nilableTypes[o.Type] = true
+	}
+	return nil
+}
+
+// overrideType looks up the column, then the exact sql type, then the type name
+func (c *Config) overrideType(table string, f *FieldInfo) (string, bool) {
+	if o, ok := c.Columns[strings.ToLower(table+"."+f.Field)]; ok {
+		return o.Type, true
+	}
+	typ := strings.ToLower(strings.Join(strings.Fields(f.Type), " "))
+	ct := parseColumnType(typ)
+	keys := []string{typ}
+	if ct.Unsigned {
+		keys = append(keys, ct.Name+" unsigned")
+	}
+	keys = append(keys, ct.Name)
+	for _, k := range keys {
+		if o, ok := c.Types[k]; ok {
+			return o.Type, true
+		}
+	}
+	return "", false
+}
+
+// renameHints returns the renamed columns of table, old name ==> new name
+func (c *Config) renameHints(table string) map[string]string {
+	hints := make(map[string]string)
+	prefix := strings.ToLower(table) + "."
+	for k, v := range c.Renames {
+		if strings.HasPrefix(k, prefix) {
+			hints[k[len(prefix):]] = v
+		}
+	}
+	return hints
+}
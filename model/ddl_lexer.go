@@ -0,0 +1,378 @@
+package mysql
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokIdent
+	tokString
+	tokNumber
+	tokSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// is reports whether t is the symbol s
+func (t token) is(s string) bool {
+	return t.kind == tokSymbol && t.text == s
+}
+
+// tokenize splits sql into tokens, comments are dropped and quotes are removed from
+// `identifiers` and 'strings'. The content of versioned comments like /*!50100 PARTITION BY ... */,
+// which SHOW CREATE TABLE and mysqldump print, is tokenized the way MySQL runs it
+func tokenize(sql string) ([]token, error) {
+	var toks []token
+	r := []rune(sql)
+	versioned := 0
+	for i := 0; i < len(r); {
+		c := r[i]
+		switch {
+		case isSpace(c):
+			i++
+		case c == '/' && i+2 < len(r) && r[i+1] == '*' && r[i+2] == '!':
+			i += 3
+			for i < len(r) && r[i] >= '0' && r[i] <= '9' {
+				i++
+			}
+			versioned++
+		case c == '*' && versioned > 0 && i+1 < len(r) && r[i+1] == '/':
+			i += 2
+			versioned--
+		case c == '#' || c == '-' && i+1 < len(r) && r[i+1] == '-' && (i+2 == len(r) || isSpace(r[i+2])):
+			for i < len(r) && r[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(r) && r[i+1] == '*':
+			end := strings.Index(string(r[i+2:]), "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i += 2 + len([]rune(string(r[i+2:])[:end])) + 2
+		case c == '`' || c == '\'' || c == '"':
+			s, n, err := readQuoted(r[i:], c)
+			if err != nil {
+				return nil, err
+			}
+			kind := tokString
+			if c == '`' {
+				kind = tokIdent
+			}
+			toks = append(toks, token{kind: kind, text: s})
+			i += n
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(r) && (r[j] >= '0' && r[j] <= '9' || r[j] == '.') {
+				j++
+			}
+			toks = append(toks, token{kind: tokNumber, text: string(r[i:j])})
+			i = j
+		case isWordRune(c):
+			j := i
+			for j < len(r) && (isWordRune(r[j]) || r[j] >= '0' && r[j] <= '9') {
+				j++
+			}
+			toks = append(toks, token{kind: tokWord, text: string(r[i:j])})
+			i = j
+		default:
+			toks = append(toks, token{kind: tokSymbol, text: string(c)})
+			i++
+		}
+	}
+	return toks, nil
+}
+
+func isSpace(c rune) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isWordRune(c rune) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c > 127
+}
+
+// readQuoted reads a quoted literal starting at r[0], doubled quotes and backslash escapes
+// are unescaped, it returns the content and the number of runes consumed
+func readQuoted(r []rune, q rune) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(r); i++ {
+		switch {
+		case r[i] == '\\' && q != '`' && i+1 < len(r):
+			i++
+			switch r[i] {
+			case 'n':
+				b.WriteRune('\n')
+			case 't':
+				b.WriteRune('\t')
+			case 'r':
+				b.WriteRune('\r')
+			case '0':
+				b.WriteRune(0)
+			default:
+				b.WriteRune(r[i])
+			}
+		case r[i] == q && i+1 < len(r) && r[i+1] == q:
+			b.WriteRune(q)
+			i++
+		case r[i] == q:
+			return b.String(), i + 1, nil
+		default:
+			b.WriteRune(r[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated quote %c", q)
+}
+
+type ddlParser struct {
+	toks []token
+	pos  int
+}
+
+func (p *ddlParser) done() bool {
+	return p.pos >= len(p.toks)
+}
+
+func (p *ddlParser) peek() token {
+	if p.done() {
+		return token{kind: tokEOF}
+	}
+	return p.toks[p.pos]
+}
+
+func (p *ddlParser) next() token {
+	t := p.peek()
+	if !p.done() {
+		p.pos++
+	}
+	return t
+}
+
+// acceptWord consumes the next token if it is the keyword w
+func (p *ddlParser) acceptWord(w string) bool {
+	if t := p.peek(); t.kind == tokWord && strings.EqualFold(t.text, w) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// ident consumes a quoted or bare identifier
+func (p *ddlParser) ident() (string, bool) {
+	t := p.peek()
+	if t.kind != tokIdent && t.kind != tokWord {
+		return "", false
+	}
+	p.pos++
+	return t.text, true
+}
+
+// group consumes a parenthesized group and returns the tokens inside it
+func (p *ddlParser) group() ([]token, bool) {
+	if !p.peek().is("(") {
+		return nil, false
+	}
+	depth := 0
+	start := p.pos + 1
+	for !p.done() {
+		t := p.next()
+		if t.is("(") {
+			depth++
+		} else if t.is(")") {
+			depth--
+			if depth == 0 {
+				return p.toks[start : p.pos-1], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// value consumes a literal after DEFAULT or ON UPDATE, NULL returns nil. TRUE and FALSE are
+// 1 and 0, as SHOW CREATE TABLE prints them
+func (p *ddlParser) value() *string {
+	t := p.next()
+	var v string
+	switch {
+	case t.kind == tokWord && strings.EqualFold(t.text, "NULL"):
+		return nil
+	case t.kind == tokWord && strings.EqualFold(t.text, "TRUE"):
+		v = "1"
+	case t.kind == tokWord && strings.EqualFold(t.text, "FALSE"):
+		v = "0"
+	case t.kind == tokWord && (strings.EqualFold(t.text, "b") || strings.EqualFold(t.text, "x")) && p.peek().kind == tokString:
+		v = strings.ToLower(t.text) + quoteString(p.next().text)
+	case t.kind == tokWord:
+		v = t.text
+		if strings.HasPrefix(strings.ToUpper(v), "CURRENT_TIMESTAMP") || strings.EqualFold(v, "now") {
+			v = "CURRENT_TIMESTAMP"
+		}
+		if p.peek().is("(") {
+			args, _ := p.group()
+			if len(args) > 0 {
+				v += "(" + joinTokens(args) + ")"
+			}
+		}
+	case t.is("-") || t.is("+"):
+		v = t.text + p.next().text
+		if t.text == "+" {
+			v = v[1:]
+		}
+	case t.is("("):
+		p.pos--
+		args, _ := p.group()
+		v = "(" + joinTokens(args) + ")"
+	default:
+		v = t.text
+	}
+	return &v
+}
+
+// splitTopLevel splits tokens on commas that are not inside parentheses
+func splitTopLevel(toks []token) [][]token {
+	var parts [][]token
+	depth, start := 0, 0
+	for i, t := range toks {
+		switch {
+		case t.is("("):
+			depth++
+		case t.is(")"):
+			depth--
+		case t.is(",") && depth == 0:
+			parts = append(parts, toks[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, toks[start:])
+}
+
+// joinTokens renders tokens back to sql without spaces around punctuation, the way
+// MySQL prints type arguments like decimal(10,2) or enum('a','b')
+func joinTokens(toks []token) string {
+	var b strings.Builder
+	for i, t := range toks {
+		if i > 0 && t.kind != tokSymbol && toks[i-1].kind != tokSymbol {
+			b.WriteString(" ")
+		}
+		switch t.kind {
+		case tokString:
+			b.WriteString(quoteString(t.text))
+		case tokIdent:
+			b.WriteString(quoteIdent(t.text))
+		default:
+			b.WriteString(t.text)
+		}
+	}
+	return b.String()
+}
+
+// exprKeywords are the words of expressions that are not columns
+var exprKeywords = map[string]bool{
+	"and": true, "or": true, "xor": true, "not": true, "null": true, "is": true, "in": true,
+	"like": true, "regexp": true, "rlike": true, "between": true, "case": true, "when": true,
+	"then": true, "else": true, "end": true, "true": true, "false": true, "div": true, "mod": true,
+	"interval": true, "as": true, "collate": true, "binary": true, "escape": true, "distinct": true,
+	"current_timestamp": true, "current_date": true, "current_time": true, "localtime": true,
+	"localtimestamp": true, "unsigned": true, "signed": true, "char": true, "date": true,
+	"datetime": true, "time": true, "decimal": true, "json": true, "year": true, "month": true,
+	"day": true, "hour": true, "minute": true, "second": true, "microsecond": true, "week": true,
+	"quarter": true, "using": true,
+}
+
+// exprString renders the tokens of a generated column, check or functional key part the same
+// way for a file and for SHOW CREATE TABLE: outer parentheses are removed, functions and
+// keywords are lower case and columns are quoted. MySQL adds parentheses around sub expressions,
+// which are kept, so a hand written expression may still differ from the one MySQL prints
+func exprString(toks []token) string {
+	for wrapped(toks) {
+		toks = toks[1 : len(toks)-1]
+	}
+	norm := make([]token, len(toks))
+	for i, t := range toks {
+		norm[i] = t
+		if t.kind != tokWord {
+			continue
+		}
+		lower := strings.ToLower(t.text)
+		switch {
+		// a function, or a charset introducer like _utf8mb4'x'
+		case i+1 < len(toks) && toks[i+1].is("("), strings.HasPrefix(t.text, "_") && i+1 < len(toks) && toks[i+1].kind == tokString:
+			norm[i].text = lower
+		case exprKeywords[lower]:
+			norm[i].text = lower
+		default:
+			norm[i].kind = tokIdent
+		}
+	}
+	return joinTokens(norm)
+}
+
+// wrapped reports whether the first token opens the parenthesis the last one closes
+func wrapped(toks []token) bool {
+	if len(toks) < 2 || !toks[0].is("(") || !toks[len(toks)-1].is(")") {
+		return false
+	}
+	p := &ddlParser{toks: toks}
+	_, ok := p.group()
+	return ok && p.done()
+}
+
+// partitionKeywords are the words of a PARTITION BY clause that are not names
+var partitionKeywords = map[string]bool{
+	"PARTITION": true, "PARTITIONS": true, "SUBPARTITION": true, "SUBPARTITIONS": true, "BY": true,
+	"HASH": true, "LINEAR": true, "KEY": true, "RANGE": true, "LIST": true, "COLUMNS": true,
+	"ALGORITHM": true, "VALUES": true, "LESS": true, "THAN": true, "IN": true, "MAXVALUE": true,
+	"COMMENT": true, "DATA": true, "INDEX": true, "DIRECTORY": true,
+	"MAX_ROWS": true, "MIN_ROWS": true, "TABLESPACE": true, "NULL": true,
+}
+
+// partitionString renders a PARTITION BY clause the same way for a file and for SHOW CREATE TABLE:
+// keywords are upper case, functions lower case and names quoted. The ENGINE of the partitions,
+// which MySQL prints and must be the one of the table, is left out
+func partitionString(all []token) string {
+	var toks []token
+	for i := 0; i < len(all); i++ {
+		if w := strings.ToUpper(all[i].text); all[i].kind == tokWord && (w == "ENGINE" || w == "STORAGE") {
+			if w == "STORAGE" {
+				i++
+			}
+			if i+1 < len(all) && all[i+1].is("=") {
+				i++
+			}
+			i++
+			continue
+		}
+		toks = append(toks, all[i])
+	}
+	var b strings.Builder
+	for i, t := range toks {
+		if i > 0 {
+			prev := toks[i-1]
+			keyword := prev.kind == tokWord && partitionKeywords[strings.ToUpper(prev.text)]
+			if !prev.is("(") && !t.is(",") && !t.is(")") && (!t.is("(") || keyword) {
+				b.WriteString(" ")
+			}
+		}
+		switch {
+		case t.kind == tokString:
+			b.WriteString(quoteString(t.text))
+		case t.kind == tokIdent:
+			b.WriteString(quoteIdent(t.text))
+		case t.kind == tokWord && partitionKeywords[strings.ToUpper(t.text)]:
+			b.WriteString(strings.ToUpper(t.text))
+		case t.kind == tokWord && i+1 < len(toks) && toks[i+1].is("("):
+			b.WriteString(strings.ToLower(t.text))
+		case t.kind == tokWord:
+			b.WriteString(quoteIdent(t.text))
+		default:
+			b.WriteString(t.text)
+		}
+	}
+	return b.String()
+}
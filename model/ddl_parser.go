@@ -0,0 +1,935 @@
+package mysql
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// Schema is the set of tables parsed from a .sql file
+type Schema struct {
+	Tables []*CreateTable
+}
+
+// CreateTable is one parsed CREATE TABLE statement
+type CreateTable struct {
+	Name        string
+	Columns     []*ColumnDef
+	Indexes     []*IndexDef
+	ForeignKeys []*ForeignKeyDef
+	Checks      []*CheckDef
+	Options     []*TableOption
+	Partition   string // PARTITION BY clause, empty without partitioning
+}
+
+// ColumnDef is a column definition inside CREATE TABLE
+type ColumnDef struct {
+	Name          string
+	Type          string
+	NotNull       bool
+	Default       *string
+	AutoIncrement bool
+	OnUpdate      string
+	Comment       string
+	Charset       string
+	Collation     *string
+	Generated     string // expression of a generated column
+	Stored        bool   // the generated column is STORED rather than VIRTUAL
+	Invisible     bool   // INVISIBLE column, MySQL 8.0.23
+
+	inlineKey    string
+	inlineChecks []*CheckDef
+}
+
+// IndexDef is a key definition inside CREATE TABLE, Kind is one of PRIMARY, UNIQUE, KEY, FULLTEXT, SPATIAL
+type IndexDef struct {
+	Name      string
+	Kind      string
+	Columns   []*IndexColumn
+	Using     string // BTREE or HASH when given
+	Comment   string
+	Invisible bool // INVISIBLE index, MySQL 8.0
+}
+
+// IndexColumn is one key part, Length is the prefix length if any. A functional key part
+// of MySQL 8.0 has Expr and no Name
+type IndexColumn struct {
+	Name   string
+	Length string
+	Expr   string
+	Desc   bool
+}
+
+// ForeignKeyDef is a FOREIGN KEY constraint, an anonymous one is named <table>_ibfk_<n> like MySQL does
+type ForeignKeyDef struct {
+	Name       string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	OnDelete   string // RESTRICT, CASCADE, SET NULL, NO ACTION or SET DEFAULT, empty when not given
+	OnUpdate   string
+
+	indexName string
+}
+
+// CheckDef is a CHECK constraint of MySQL 8.0.16, an anonymous one is named <table>_chk_<n>.
+// Expr is the condition without its outer parentheses
+type CheckDef struct {
+	Name        string
+	Expr        string
+	NotEnforced bool
+}
+
+// TableOption is a table option after the closing paren, like ENGINE=InnoDB
+type TableOption struct {
+	Name  string
+	Value string
+}
+
+// ParseSchemaFile reads a .sql file and parses all CREATE TABLE statements in it
+func ParseSchemaFile(file string) (*Schema, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSchema(string(b))
+}
+
+// ParseSchema parses all CREATE TABLE statements in sql, other statements are skipped
+func ParseSchema(sql string) (*Schema, error) {
+	toks, err := tokenize(sql)
+	if err != nil {
+		return nil, err
+	}
+	s := &Schema{}
+	var stmt []token
+	for _, t := range append(toks, token{kind: tokSymbol, text: ";"}) {
+		if !t.is(";") {
+			stmt = append(stmt, t)
+			continue
+		}
+		if len(stmt) > 0 {
+			ct, err := parseCreateTable(stmt)
+			if err != nil {
+				return nil, err
+			}
+			if ct != nil {
+				s.Tables = append(s.Tables, ct)
+			}
+		}
+		stmt = stmt[:0]
+	}
+	return s, nil
+}
+
+// Table returns the table by name, a database qualifier like d_user.t_user is ignored
+func (s *Schema) Table(name string) *CreateTable {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, t := range s.Tables {
+		if strings.EqualFold(t.Name, name) {
+			return t
+		}
+	}
+	return nil
+}
+
+// TableNames returns the table names in file order
+func (s *Schema) TableNames() []string {
+	names := make([]string, 0, len(s.Tables))
+	for _, t := range s.Tables {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// Column returns the column by name
+func (c *CreateTable) Column(name string) *ColumnDef {
+	for _, col := range c.Columns {
+		if strings.EqualFold(col.Name, name) {
+			return col
+		}
+	}
+	return nil
+}
+
+// Fields converts the columns into the same result as `show full columns`
+func (c *CreateTable) Fields() []*FieldInfo {
+	keys := make(map[string]string)
+	for _, idx := range c.Indexes {
+		// a functional key part has no column
+		if len(idx.Columns) == 0 || len(idx.Columns[0].Name) == 0 {
+			continue
+		}
+		if idx.Kind == "PRIMARY" {
+			for _, ic := range idx.Columns {
+				keys[ic.Name] = "PRI"
+			}
+			continue
+		}
+		first := idx.Columns[0].Name
+		switch {
+		case keys[first] == "PRI" || keys[first] == "UNI":
+		case idx.Kind == "UNIQUE" && len(idx.Columns) == 1:
+			keys[first] = "UNI"
+		default:
+			keys[first] = "MUL"
+		}
+	}
+
+	fields := make([]*FieldInfo, 0, len(c.Columns))
+	for _, col := range c.Columns {
+		null := "YES"
+		if col.NotNull {
+			null = "NO"
+		}
+		var extra []string
+		if col.AutoIncrement {
+			extra = append(extra, "auto_increment")
+		}
+		if len(col.OnUpdate) > 0 {
+			extra = append(extra, "on update "+col.OnUpdate)
+		}
+		if len(col.Generated) > 0 && col.Stored {
+			extra = append(extra, "STORED GENERATED")
+		} else if len(col.Generated) > 0 {
+			extra = append(extra, "VIRTUAL GENERATED")
+		}
+		if col.Invisible {
+			extra = append(extra, "INVISIBLE")
+		}
+		e := strings.Join(extra, " ")
+		fields = append(fields, &FieldInfo{
+			Field:      col.Name,
+			Type:       col.Type,
+			Comment:    col.Comment,
+			Collation:  col.Collation,
+			Null:       null,
+			Key:        keys[col.Name],
+			Default:    col.Default,
+			Extra:      &e,
+			Privileges: "select,insert,update,references",
+		})
+	}
+	return fields
+}
+
+// IndexInfos converts the keys into the same result as information_schema.STATISTICS
+func (c *CreateTable) IndexInfos() []*IndexInfo {
+	var res []*IndexInfo
+	for _, idx := range c.Indexes {
+		nonUnique := 1
+		if idx.Kind == "PRIMARY" || idx.Kind == "UNIQUE" {
+			nonUnique = 0
+		}
+		indexType := "BTREE"
+		if idx.Kind == "FULLTEXT" || idx.Kind == "SPATIAL" {
+			indexType = idx.Kind
+		}
+		for i, ic := range idx.Columns {
+			info := &IndexInfo{
+				KeyName:    idx.Name,
+				ColumnName: ic.Name,
+				NonUnique:  nonUnique,
+				SeqInIndex: i + 1,
+				IndexType:  indexType,
+			}
+			if n, err := strconv.ParseInt(ic.Length, 10, 64); err == nil {
+				info.SubPart = &n
+			}
+			res = append(res, info)
+		}
+	}
+	return res
+}
+
+// ForeignKeyInfos converts the foreign keys into the same result as information_schema.KEY_COLUMN_USAGE,
+// a rule not given is NO ACTION like MySQL 8 reports it
+func (c *CreateTable) ForeignKeyInfos() []*ForeignKeyInfo {
+	var res []*ForeignKeyInfo
+	rule := func(r string) string {
+		if len(r) == 0 {
+			return "NO ACTION"
+		}
+		return r
+	}
+	for _, fk := range c.ForeignKeys {
+		schema, table := "", fk.RefTable
+		if i := strings.LastIndex(table, "."); i >= 0 {
+			schema, table = table[:i], table[i+1:]
+		}
+		for i, col := range fk.Columns {
+			info := &ForeignKeyInfo{
+				ConstraintName:  fk.Name,
+				ColumnName:      col,
+				OrdinalPosition: i + 1,
+				RefSchema:       schema,
+				RefTable:        table,
+				UpdateRule:      rule(fk.OnUpdate),
+				DeleteRule:      rule(fk.OnDelete),
+			}
+			if i < len(fk.RefColumns) {
+				info.RefColumn = fk.RefColumns[i]
+			}
+			res = append(res, info)
+		}
+	}
+	return res
+}
+
+// DDL renders the table the way `SHOW CREATE TABLE` does, so snapshots from a file
+// and from a live database can be compared line by line
+func (c *CreateTable) DDL() string {
+	defs := make([]string, 0, len(c.Columns)+len(c.Indexes)+len(c.ForeignKeys)+len(c.Checks))
+	for _, col := range c.Columns {
+		defs = append(defs, "  "+col.String())
+	}
+	for _, idx := range c.Indexes {
+		defs = append(defs, "  "+idx.String())
+	}
+	for _, fk := range c.ForeignKeys {
+		defs = append(defs, "  "+fk.String())
+	}
+	for _, ck := range c.Checks {
+		defs = append(defs, "  "+ck.String())
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", quoteIdent(c.Name)))
+	b.WriteString(strings.Join(defs, ",\n"))
+	b.WriteString("\n) ")
+	b.WriteString(c.optionString())
+	if len(c.Partition) > 0 {
+		b.WriteString("\n" + c.Partition)
+	}
+	return b.String()
+}
+
+func (c *CreateTable) optionString() string {
+	opts := make([]string, 0, len(c.Options)+1)
+	hasEngine := false
+	for _, o := range c.Options {
+		if o.Name == "ENGINE" {
+			hasEngine = true
+		}
+		opts = append(opts, o.Name+"="+o.Value)
+	}
+	// SHOW CREATE TABLE always prints the engine
+	if !hasEngine {
+		opts = append([]string{"ENGINE=InnoDB"}, opts...)
+	}
+	return strings.Join(opts, " ")
+}
+
+func (c *ColumnDef) String() string {
+	s := []string{quoteIdent(c.Name), c.Type}
+	if len(c.Charset) > 0 {
+		s = append(s, "CHARACTER SET "+c.Charset)
+	}
+	if c.Collation != nil {
+		s = append(s, "COLLATE "+*c.Collation)
+	}
+	if len(c.Generated) > 0 {
+		kind := "VIRTUAL"
+		if c.Stored {
+			kind = "STORED"
+		}
+		s = append(s, fmt.Sprintf("GENERATED ALWAYS AS (%s) %s", c.Generated, kind))
+	}
+	if c.NotNull {
+		s = append(s, "NOT NULL")
+	} else if parseColumnType(c.Type).Name == "timestamp" {
+		// without it MySQL 5.7 makes a TIMESTAMP NOT NULL when explicit_defaults_for_timestamp is OFF
+		s = append(s, "NULL")
+	}
+	if c.AutoIncrement {
+		s = append(s, "AUTO_INCREMENT")
+	}
+	if c.Default != nil {
+		s = append(s, "DEFAULT "+formatDefault(*c.Default))
+	} else if !c.NotNull && !c.AutoIncrement && len(c.Generated) == 0 {
+		s = append(s, "DEFAULT NULL")
+	}
+	if len(c.OnUpdate) > 0 {
+		s = append(s, "ON UPDATE "+c.OnUpdate)
+	}
+	if c.Invisible {
+		s = append(s, "INVISIBLE")
+	}
+	if len(c.Comment) > 0 {
+		s = append(s, "COMMENT "+quoteString(c.Comment))
+	}
+	return strings.Join(s, " ")
+}
+
+func (i *IndexDef) String() string {
+	cols := make([]string, 0, len(i.Columns))
+	for _, c := range i.Columns {
+		col := quoteIdent(c.Name)
+		if len(c.Expr) > 0 {
+			col = "(" + c.Expr + ")"
+		} else if len(c.Length) > 0 {
+			col += "(" + c.Length + ")"
+		}
+		if c.Desc {
+			col += " DESC"
+		}
+		cols = append(cols, col)
+	}
+	s := "(" + strings.Join(cols, ",") + ")"
+	switch i.Kind {
+	case "PRIMARY":
+		s = "PRIMARY KEY " + s
+	case "KEY":
+		s = fmt.Sprintf("KEY %s %s", quoteIdent(i.Name), s)
+	default:
+		s = fmt.Sprintf("%s KEY %s %s", i.Kind, quoteIdent(i.Name), s)
+	}
+	if len(i.Using) > 0 {
+		s += " USING " + i.Using
+	}
+	if len(i.Comment) > 0 {
+		s += " COMMENT " + quoteString(i.Comment)
+	}
+	if i.Invisible {
+		s += " INVISIBLE"
+	}
+	return s
+}
+
+func (f *ForeignKeyDef) String() string {
+	cols := make([]string, 0, len(f.Columns))
+	for _, c := range f.Columns {
+		cols = append(cols, quoteIdent(c))
+	}
+	refs := make([]string, 0, len(f.RefColumns))
+	for _, c := range f.RefColumns {
+		refs = append(refs, quoteIdent(c))
+	}
+	table := make([]string, 0, 2)
+	for _, t := range strings.Split(f.RefTable, ".") {
+		table = append(table, quoteIdent(t))
+	}
+	s := fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)", quoteIdent(f.Name),
+		strings.Join(cols, ","), strings.Join(table, "."), strings.Join(refs, ","))
+	if len(f.OnDelete) > 0 {
+		s += " ON DELETE " + f.OnDelete
+	}
+	if len(f.OnUpdate) > 0 {
+		s += " ON UPDATE " + f.OnUpdate
+	}
+	return s
+}
+
+func (c *CheckDef) String() string {
+	s := fmt.Sprintf("CONSTRAINT %s CHECK (%s)", quoteIdent(c.Name), c.Expr)
+	if c.NotEnforced {
+		s += " NOT ENFORCED"
+	}
+	return s
+}
+
+// ColumnNames returns the names of the key parts
+func (i *IndexDef) ColumnNames() []string {
+	names := make([]string, 0, len(i.Columns))
+	for _, c := range i.Columns {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func formatDefault(v string) string {
+	u := strings.ToUpper(v)
+	if strings.HasPrefix(u, "CURRENT_TIMESTAMP") || strings.HasPrefix(v, "(") ||
+		strings.HasPrefix(v, "b'") || strings.HasPrefix(v, "x'") {
+		return v
+	}
+	return quoteString(v)
+}
+
+func quoteIdent(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "``") + "`"
+}
+
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func parseCreateTable(stmt []token) (*CreateTable, error) {
+	p := &ddlParser{toks: stmt}
+	if !p.acceptWord("CREATE") {
+		return nil, nil
+	}
+	p.acceptWord("TEMPORARY")
+	if !p.acceptWord("TABLE") {
+		return nil, nil
+	}
+	if p.acceptWord("IF") {
+		p.acceptWord("NOT")
+		p.acceptWord("EXISTS")
+	}
+	name, ok := p.ident()
+	if !ok {
+		return nil, fmt.Errorf("create table: missing table name near %q", p.peek().text)
+	}
+	for p.peek().is(".") {
+		p.next()
+		if name, ok = p.ident(); !ok {
+			return nil, fmt.Errorf("create table: bad table name near %q", p.peek().text)
+		}
+	}
+	// CREATE TABLE ... LIKE / AS SELECT has no column list to generate from
+	if !p.peek().is("(") {
+		return nil, nil
+	}
+
+	ct := &CreateTable{Name: name}
+	body, ok := p.group()
+	if !ok {
+		return nil, fmt.Errorf("create table %s: unbalanced parentheses", name)
+	}
+	for _, def := range splitTopLevel(body) {
+		if len(def) == 0 {
+			continue
+		}
+		if err := ct.parseDefinition(def); err != nil {
+			return nil, fmt.Errorf("create table %s: %v", name, err)
+		}
+	}
+	ct.promoteInlineKeys()
+	// the keys a foreign key can use may follow it, or be inline
+	for _, fk := range ct.ForeignKeys {
+		ct.foreignKeyIndex(fk)
+	}
+	ct.markPrimaryNotNull()
+	ct.nameConstraints()
+	ct.parseOptions(p)
+	return ct, nil
+}
+
+func (c *CreateTable) parseDefinition(def []token) error {
+	p := &ddlParser{toks: def}
+	first := p.peek()
+	if first.kind == tokWord {
+		switch strings.ToUpper(first.text) {
+		case "CONSTRAINT":
+			p.next()
+			var symbol string
+			if w := strings.ToUpper(p.peek().text); p.peek().kind != tokWord ||
+				(w != "PRIMARY" && w != "UNIQUE" && w != "FOREIGN" && w != "CHECK") {
+				symbol = p.next().text
+			}
+			return c.parseConstraint(p, symbol)
+		case "PRIMARY", "UNIQUE", "KEY", "INDEX", "FULLTEXT", "SPATIAL", "FOREIGN", "CHECK":
+			return c.parseConstraint(p, "")
+		}
+	}
+	col, err := parseColumn(p)
+	if err != nil {
+		return err
+	}
+	c.Columns = append(c.Columns, col)
+	c.Checks = append(c.Checks, col.inlineChecks...)
+	col.inlineChecks = nil
+	return nil
+}
+
+// parseConstraint parses a key, foreign key or check, symbol is the CONSTRAINT name if any
+func (c *CreateTable) parseConstraint(p *ddlParser, symbol string) error {
+	switch w := strings.ToUpper(p.next().text); w {
+	case "FOREIGN":
+		return c.parseForeignKey(p, symbol)
+	case "CHECK":
+		ck, err := parseCheck(p, symbol)
+		if err != nil {
+			return err
+		}
+		c.Checks = append(c.Checks, ck)
+		return nil
+	default:
+		return c.parseIndex(p, w, symbol)
+	}
+}
+
+func (c *CreateTable) parseIndex(p *ddlParser, kind, symbol string) error {
+	idx := &IndexDef{}
+	switch kind {
+	case "PRIMARY":
+		p.acceptWord("KEY")
+		idx.Kind = "PRIMARY"
+	case "UNIQUE", "FULLTEXT", "SPATIAL":
+		if !p.acceptWord("KEY") {
+			p.acceptWord("INDEX")
+		}
+		idx.Kind = kind
+	case "KEY", "INDEX":
+		idx.Kind = "KEY"
+	default:
+		return fmt.Errorf("unexpected %q", kind)
+	}
+	if t := p.peek(); !p.peek().is("(") && !(t.kind == tokWord && strings.EqualFold(t.text, "USING")) {
+		name, _ := p.ident()
+		idx.Name = name
+	}
+	if len(idx.Name) == 0 {
+		idx.Name = symbol
+	}
+	if p.acceptWord("USING") {
+		idx.Using = strings.ToUpper(p.next().text)
+	}
+	parts, ok := p.group()
+	if !ok {
+		return fmt.Errorf("key %s: missing column list", idx.Name)
+	}
+	for _, part := range splitTopLevel(parts) {
+		if len(part) == 0 {
+			continue
+		}
+		pp := &ddlParser{toks: part}
+		ic := &IndexColumn{}
+		if pp.peek().is("(") {
+			expr, _ := pp.group()
+			ic.Expr = exprString(expr)
+		} else {
+			name, ok := pp.ident()
+			if !ok {
+				return fmt.Errorf("key %s: unsupported key part near %q", idx.Name, part[0].text)
+			}
+			ic.Name = name
+			if pp.peek().is("(") {
+				length, _ := pp.group()
+				ic.Length = joinTokens(length)
+			}
+		}
+		if pp.acceptWord("DESC") {
+			ic.Desc = true
+		}
+		idx.Columns = append(idx.Columns, ic)
+	}
+	// index options
+	for !p.done() {
+		t := p.next()
+		switch strings.ToUpper(t.text) {
+		case "USING":
+			idx.Using = strings.ToUpper(p.next().text)
+		case "COMMENT":
+			if v := p.next(); v.kind == tokString {
+				idx.Comment = v.text
+			}
+		case "INVISIBLE":
+			idx.Invisible = true
+		case "VISIBLE":
+			idx.Invisible = false
+		}
+	}
+	if idx.Kind == "PRIMARY" {
+		idx.Name = "PRIMARY"
+	} else if len(idx.Name) == 0 && len(idx.Columns) > 0 {
+		// MySQL names an anonymous key after its first column, functional_index for an expression
+		idx.Name = idx.Columns[0].Name
+		if len(idx.Name) == 0 {
+			idx.Name = "functional_index"
+		}
+	}
+	c.Indexes = append(c.Indexes, idx)
+	return nil
+}
+
+// parseForeignKey parses FOREIGN KEY [name] (cols) REFERENCES table (cols) [MATCH ...] [ON DELETE ...] [ON UPDATE ...]
+func (c *CreateTable) parseForeignKey(p *ddlParser, symbol string) error {
+	p.acceptWord("KEY")
+	fk := &ForeignKeyDef{Name: symbol}
+	if !p.peek().is("(") {
+		fk.indexName, _ = p.ident()
+	}
+	cols, ok := p.group()
+	if !ok {
+		return fmt.Errorf("foreign key %s: missing column list", symbol)
+	}
+	fk.Columns = identList(cols)
+	if !p.acceptWord("REFERENCES") {
+		return fmt.Errorf("foreign key %s: missing REFERENCES", symbol)
+	}
+	table, ok := p.ident()
+	if !ok {
+		return fmt.Errorf("foreign key %s: missing referenced table", symbol)
+	}
+	for p.peek().is(".") {
+		p.next()
+		t, _ := p.ident()
+		table += "." + t
+	}
+	fk.RefTable = table
+	refs, ok := p.group()
+	if !ok {
+		return fmt.Errorf("foreign key %s: missing referenced columns", symbol)
+	}
+	fk.RefColumns = identList(refs)
+	for !p.done() {
+		t := p.next()
+		if !strings.EqualFold(t.text, "ON") {
+			continue
+		}
+		event := strings.ToUpper(p.next().text)
+		action := strings.ToUpper(p.next().text)
+		if action == "SET" || action == "NO" {
+			action += " " + strings.ToUpper(p.next().text)
+		}
+		if event == "DELETE" {
+			fk.OnDelete = action
+		} else if event == "UPDATE" {
+			fk.OnUpdate = action
+		}
+	}
+	c.ForeignKeys = append(c.ForeignKeys, fk)
+	return nil
+}
+
+// foreignKeyIndex adds the key MySQL creates for a foreign key without a usable one, named
+// after the constraint, then the index name, then the first column
+func (c *CreateTable) foreignKeyIndex(fk *ForeignKeyDef) {
+	indexName := fk.indexName
+	fk.indexName = ""
+	for _, idx := range c.Indexes {
+		if len(idx.Columns) < len(fk.Columns) {
+			continue
+		}
+		usable := true
+		for i, col := range fk.Columns {
+			usable = usable && strings.EqualFold(idx.Columns[i].Name, col) && len(idx.Columns[i].Length) == 0
+		}
+		if usable {
+			return
+		}
+	}
+	name := fk.Name
+	if len(name) == 0 {
+		name = indexName
+	}
+	if len(name) == 0 {
+		name = fk.Columns[0]
+	}
+	idx := &IndexDef{Name: name, Kind: "KEY"}
+	for _, col := range fk.Columns {
+		idx.Columns = append(idx.Columns, &IndexColumn{Name: col})
+	}
+	c.Indexes = append(c.Indexes, idx)
+}
+
+// parseCheck parses (expr) [[NOT] ENFORCED] after CHECK
+func parseCheck(p *ddlParser, symbol string) (*CheckDef, error) {
+	expr, ok := p.group()
+	if !ok {
+		return nil, fmt.Errorf("check %s: missing condition", symbol)
+	}
+	ck := &CheckDef{Name: symbol, Expr: exprString(expr)}
+	if p.acceptWord("NOT") {
+		ck.NotEnforced = p.acceptWord("ENFORCED")
+	} else {
+		p.acceptWord("ENFORCED")
+	}
+	return ck, nil
+}
+
+// nameConstraints names the anonymous foreign keys and checks the way MySQL does
+func (c *CreateTable) nameConstraints() {
+	n := 0
+	for _, fk := range c.ForeignKeys {
+		if len(fk.Name) == 0 {
+			n++
+			fk.Name = fmt.Sprintf("%s_ibfk_%d", c.Name, n)
+		}
+	}
+	n = 0
+	for _, ck := range c.Checks {
+		if len(ck.Name) == 0 {
+			n++
+			ck.Name = fmt.Sprintf("%s_chk_%d", c.Name, n)
+		}
+	}
+}
+
+func identList(toks []token) []string {
+	var names []string
+	for _, part := range splitTopLevel(toks) {
+		if len(part) > 0 {
+			names = append(names, part[0].text)
+		}
+	}
+	return names
+}
+
+func parseColumn(p *ddlParser) (*ColumnDef, error) {
+	name, ok := p.ident()
+	if !ok {
+		return nil, fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	col := &ColumnDef{Name: name}
+	t := p.next()
+	if t.kind != tokWord {
+		return nil, fmt.Errorf("column %s: missing type", name)
+	}
+	typ := strings.ToLower(t.text)
+	if p.peek().is("(") {
+		args, _ := p.group()
+		typ += "(" + joinTokens(args) + ")"
+	}
+	for {
+		w := strings.ToLower(p.peek().text)
+		if p.peek().kind != tokWord || (w != "unsigned" && w != "zerofill" && w != "signed") {
+			break
+		}
+		p.next()
+		if w != "signed" {
+			typ += " " + w
+		}
+	}
+	col.Type = typ
+
+	var inlineKey, symbol string
+	for !p.done() {
+		t := p.next()
+		if t.kind != tokWord {
+			continue
+		}
+		switch strings.ToUpper(t.text) {
+		case "NOT":
+			if p.acceptWord("NULL") {
+				col.NotNull = true
+			}
+		case "DEFAULT":
+			col.Default = p.value()
+		case "AUTO_INCREMENT":
+			col.AutoIncrement = true
+		case "ON":
+			if p.acceptWord("UPDATE") {
+				if v := p.value(); v != nil {
+					col.OnUpdate = *v
+				}
+			}
+		case "COMMENT":
+			if v := p.next(); v.kind == tokString {
+				col.Comment = v.text
+			}
+		case "CHARACTER":
+			p.acceptWord("SET")
+			col.Charset = p.next().text
+		case "CHARSET":
+			col.Charset = p.next().text
+		case "COLLATE":
+			c := p.next().text
+			col.Collation = &c
+		case "AS":
+			expr, _ := p.group()
+			col.Generated = exprString(expr)
+		case "STORED", "PERSISTENT":
+			col.Stored = true
+		case "INVISIBLE":
+			col.Invisible = true
+		case "CONSTRAINT":
+			if !strings.EqualFold(p.peek().text, "CHECK") {
+				symbol = p.next().text
+			}
+		case "CHECK":
+			ck, err := parseCheck(p, symbol)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %v", name, err)
+			}
+			col.inlineChecks = append(col.inlineChecks, ck)
+			symbol = ""
+		case "REFERENCES":
+			// MySQL parses and ignores an inline foreign key
+			p.ident()
+			for p.peek().is(".") {
+				p.next()
+				p.ident()
+			}
+			p.group()
+		case "PRIMARY":
+			p.acceptWord("KEY")
+			inlineKey = "PRIMARY"
+		case "UNIQUE":
+			p.acceptWord("KEY")
+			inlineKey = "UNIQUE"
+		case "KEY":
+			inlineKey = "PRIMARY"
+		}
+	}
+	// inline keys are rendered as separate definitions, the same as SHOW CREATE TABLE
+	col.inlineKey = inlineKey
+	return col, nil
+}
+
+func (c *CreateTable) parseOptions(p *ddlParser) {
+	for !p.done() {
+		t := p.next()
+		if t.kind != tokWord {
+			continue
+		}
+		name := strings.ToUpper(t.text)
+		if name == "DEFAULT" {
+			continue
+		}
+		if name == "PARTITION" {
+			c.Partition = partitionString(p.toks[p.pos-1:])
+			return
+		}
+		if name == "CHARACTER" && p.acceptWord("SET") {
+			name = "CHARSET"
+		}
+		if (name == "DATA" || name == "INDEX") && p.acceptWord("DIRECTORY") {
+			name += " DIRECTORY"
+		}
+		if p.peek().is("=") {
+			p.next()
+		}
+		if p.peek().is("(") {
+			group, _ := p.group()
+			c.Options = append(c.Options, &TableOption{Name: name, Value: "(" + joinTokens(group) + ")"})
+			continue
+		}
+		v := p.next()
+		if v.kind == tokEOF {
+			break
+		}
+		value := v.text
+		if v.kind == tokString {
+			value = quoteString(v.text)
+		}
+		if name == "CHARSET" {
+			name = "DEFAULT CHARSET"
+		}
+		c.Options = append(c.Options, &TableOption{Name: name, Value: value})
+	}
+}
+
+// markPrimaryNotNull makes primary key columns NOT NULL, MySQL does it implicitly
+func (c *CreateTable) markPrimaryNotNull() {
+	for _, idx := range c.Indexes {
+		if idx.Kind != "PRIMARY" {
+			continue
+		}
+		for _, ic := range idx.Columns {
+			if col := c.Column(ic.Name); col != nil {
+				col.NotNull = true
+			}
+		}
+	}
+}
+
+// promoteInlineKeys turns `id int PRIMARY KEY` into a table level key
+func (c *CreateTable) promoteInlineKeys() {
+	var inline []*IndexDef
+	for _, col := range c.Columns {
+		switch col.inlineKey {
+		case "PRIMARY":
+			inline = append(inline, &IndexDef{Name: "PRIMARY", Kind: "PRIMARY", Columns: []*IndexColumn{{Name: col.Name}}})
+		case "UNIQUE":
+			inline = append(inline, &IndexDef{Name: col.Name, Kind: "UNIQUE", Columns: []*IndexColumn{{Name: col.Name}}})
+		}
+		col.inlineKey = ""
+	}
+	c.Indexes = append(inline, c.Indexes...)
+}
@@ -0,0 +1,195 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSchemaRoundTrip(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cases := []struct {
+		name  string
+		sql   string
+		table *CreateTable
+		ddl   string
+	}{
+		{
+			name: "mysql 5.7",
+			sql: "CREATE TABLE `t_user` (\n" +
+				"  `id` int(11) NOT NULL AUTO_INCREMENT,\n" +
+				"  `name` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL DEFAULT '' COMMENT 'user''s name',\n" +
+				"  `login_at` timestamp NULL DEFAULT NULL,\n" +
+				"  `deleted_at` datetime DEFAULT NULL,\n" +
+				"  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n" +
+				"  PRIMARY KEY (`id`),\n" +
+				"  KEY `idx_name` (`name`(10))\n" +
+				") ENGINE=InnoDB AUTO_INCREMENT=12 DEFAULT CHARSET=utf8mb4 COMMENT='users'\n" +
+				"/*!50100 PARTITION BY HASH (`id`)\nPARTITIONS 4 */;",
+			table: &CreateTable{
+				Name: "t_user",
+				Columns: []*ColumnDef{
+					{Name: "id", Type: "int(11)", NotNull: true, AutoIncrement: true},
+					{Name: "name", Type: "varchar(64)", NotNull: true, Default: str(""), Comment: "user's name", Charset: "utf8mb4", Collation: str("utf8mb4_bin")},
+					{Name: "login_at", Type: "timestamp"},
+					{Name: "deleted_at", Type: "datetime"},
+					{Name: "updated_at", Type: "timestamp", NotNull: true, Default: str("CURRENT_TIMESTAMP"), OnUpdate: "CURRENT_TIMESTAMP"},
+				},
+				Indexes: []*IndexDef{
+					{Name: "PRIMARY", Kind: "PRIMARY", Columns: []*IndexColumn{{Name: "id"}}},
+					{Name: "idx_name", Kind: "KEY", Columns: []*IndexColumn{{Name: "name", Length: "10"}}},
+				},
+				Options: []*TableOption{
+					{Name: "ENGINE", Value: "InnoDB"},
+					{Name: "AUTO_INCREMENT", Value: "12"},
+					{Name: "DEFAULT CHARSET", Value: "utf8mb4"},
+					{Name: "COMMENT", Value: "'users'"},
+				},
+				Partition: "PARTITION BY HASH (`id`) PARTITIONS 4",
+			},
+			ddl: "CREATE TABLE `t_user` (\n" +
+				"  `id` int(11) NOT NULL AUTO_INCREMENT,\n" +
+				"  `name` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL DEFAULT '' COMMENT 'user''s name',\n" +
+				"  `login_at` timestamp NULL DEFAULT NULL,\n" +
+				"  `deleted_at` datetime DEFAULT NULL,\n" +
+				"  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n" +
+				"  PRIMARY KEY (`id`),\n" +
+				"  KEY `idx_name` (`name`(10))\n" +
+				") ENGINE=InnoDB AUTO_INCREMENT=12 DEFAULT CHARSET=utf8mb4 COMMENT='users'\n" +
+				"PARTITION BY HASH (`id`) PARTITIONS 4",
+		},
+		{
+			name: "mysql 8.0",
+			sql: "CREATE TABLE `t_order` (\n" +
+				"  `id` bigint NOT NULL,\n" +
+				"  `user_id` int NOT NULL,\n" +
+				"  `email` varchar(64) NOT NULL,\n" +
+				"  `qty` int NOT NULL DEFAULT (1),\n" +
+				"  `uuid` binary(16) NOT NULL DEFAULT (uuid_to_bin(uuid())),\n" +
+				"  `note` varchar(10) DEFAULT NULL /*!80023 INVISIBLE */,\n" +
+				"  PRIMARY KEY (`id`),\n" +
+				"  KEY `idx_email` ((lower(`email`))),\n" +
+				"  KEY `idx_user` (`user_id`) INVISIBLE,\n" +
+				"  CONSTRAINT `fk_user` FOREIGN KEY (`user_id`) REFERENCES `t_user` (`id`) ON DELETE CASCADE,\n" +
+				"  CONSTRAINT `chk_qty` CHECK ((`qty` > 0))\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;",
+			table: &CreateTable{
+				Name: "t_order",
+				Columns: []*ColumnDef{
+					{Name: "id", Type: "bigint", NotNull: true},
+					{Name: "user_id", Type: "int", NotNull: true},
+					{Name: "email", Type: "varchar(64)", NotNull: true},
+					{Name: "qty", Type: "int", NotNull: true, Default: str("(1)")},
+					{Name: "uuid", Type: "binary(16)", NotNull: true, Default: str("(uuid_to_bin(uuid()))")},
+					{Name: "note", Type: "varchar(10)", Invisible: true},
+				},
+				Indexes: []*IndexDef{
+					{Name: "PRIMARY", Kind: "PRIMARY", Columns: []*IndexColumn{{Name: "id"}}},
+					{Name: "idx_email", Kind: "KEY", Columns: []*IndexColumn{{Expr: "lower(`email`)"}}},
+					{Name: "idx_user", Kind: "KEY", Columns: []*IndexColumn{{Name: "user_id"}}, Invisible: true},
+				},
+				ForeignKeys: []*ForeignKeyDef{
+					{Name: "fk_user", Columns: []string{"user_id"}, RefTable: "t_user", RefColumns: []string{"id"}, OnDelete: "CASCADE"},
+				},
+				Checks: []*CheckDef{{Name: "chk_qty", Expr: "`qty`>0"}},
+				Options: []*TableOption{
+					{Name: "ENGINE", Value: "InnoDB"},
+					{Name: "DEFAULT CHARSET", Value: "utf8mb4"},
+					{Name: "COLLATE", Value: "utf8mb4_0900_ai_ci"},
+				},
+			},
+			ddl: "CREATE TABLE `t_order` (\n" +
+				"  `id` bigint NOT NULL,\n" +
+				"  `user_id` int NOT NULL,\n" +
+				"  `email` varchar(64) NOT NULL,\n" +
+				"  `qty` int NOT NULL DEFAULT (1),\n" +
+				"  `uuid` binary(16) NOT NULL DEFAULT (uuid_to_bin(uuid())),\n" +
+				"  `note` varchar(10) DEFAULT NULL INVISIBLE,\n" +
+				"  PRIMARY KEY (`id`),\n" +
+				"  KEY `idx_email` ((lower(`email`))),\n" +
+				"  KEY `idx_user` (`user_id`) INVISIBLE,\n" +
+				"  CONSTRAINT `fk_user` FOREIGN KEY (`user_id`) REFERENCES `t_user` (`id`) ON DELETE CASCADE,\n" +
+				"  CONSTRAINT `chk_qty` CHECK (`qty`>0)\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s, err := ParseSchema(c.sql)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(s.Tables) != 1 {
+				t.Fatalf("%d tables, want 1", len(s.Tables))
+			}
+			if !reflect.DeepEqual(s.Tables[0], c.table) {
+				t.Errorf("parsed %+v, want %+v", s.Tables[0], c.table)
+			}
+			ddl := s.Tables[0].DDL()
+			if ddl != c.ddl {
+				t.Errorf("DDL() =\n%s\nwant\n%s", ddl, c.ddl)
+			}
+			// the rendered DDL parses back to the same table
+			again, err := ParseSchema(ddl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(again.Tables[0], c.table) {
+				t.Errorf("round trip parsed %+v, want %+v", again.Tables[0], c.table)
+			}
+		})
+	}
+}
+
+func TestParseForeignKeyIndex(t *testing.T) {
+	cases := []struct {
+		name    string
+		sql     string
+		indexes []string
+	}{
+		{"key after the foreign key",
+			"CREATE TABLE t (id int, user_id int, CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES u (id), KEY idx_user (user_id))",
+			[]string{"idx_user"}},
+		{"inline unique",
+			"CREATE TABLE t (id int, user_id int UNIQUE, FOREIGN KEY (user_id) REFERENCES u (id))",
+			[]string{"user_id"}},
+		{"prefix of a later key",
+			"CREATE TABLE t (id int, a int, b int, FOREIGN KEY (a) REFERENCES u (id), KEY idx_ab (a, b))",
+			[]string{"idx_ab"}},
+		{"no usable key",
+			"CREATE TABLE t (id int, a int, b int, CONSTRAINT fk_b FOREIGN KEY (b) REFERENCES u (id), KEY idx_ab (a, b))",
+			[]string{"idx_ab", "fk_b"}},
+		{"index name",
+			"CREATE TABLE t (id int, a int, FOREIGN KEY idx_a (a) REFERENCES u (id))",
+			[]string{"idx_a"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s, err := ParseSchema(c.sql)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, idx := range s.Tables[0].Indexes {
+				names = append(names, idx.Name)
+			}
+			if !reflect.DeepEqual(names, c.indexes) {
+				t.Errorf("indexes = %v, want %v", names, c.indexes)
+			}
+		})
+	}
+}
+
+func TestParseDefaultBool(t *testing.T) {
+	s, err := ParseSchema("CREATE TABLE t (id int NOT NULL, active tinyint(1) NOT NULL DEFAULT TRUE, deleted bool DEFAULT false)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "CREATE TABLE `t` (\n" +
+		"  `id` int NOT NULL,\n" +
+		"  `active` tinyint(1) NOT NULL DEFAULT '1',\n" +
+		"  `deleted` bool DEFAULT '0'\n" +
+		") ENGINE=InnoDB"
+	if ddl := s.Tables[0].DDL(); ddl != want {
+		t.Errorf("DDL() =\n%s\nwant\n%s", ddl, want)
+	}
+}
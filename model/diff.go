@@ -0,0 +1,207 @@
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+	driver "github.com/go-sql-driver/mysql"
+)
+
+// OpenSchema opens a .sql file, or a DSN like user:password@tcp(127.0.0.1:3306)/d_user,
+// and returns it with the name of its database, which is empty for a file
+func OpenSchema(target string) (SchemaSource, string, error) {
+	if strings.HasSuffix(strings.ToLower(target), ".sql") {
+		if _, err := os.Stat(target); err == nil {
+			s, err := ParseSchemaFile(target)
+			if err != nil {
+				return nil, "", fmt.Errorf("parse schema file [%s] err: %v", target, err)
+			}
+			return NewSchemaSource(s), "", nil
+		}
+	}
+	cfg, err := driver.ParseDSN(target)
+	if err != nil {
+		return nil, "", fmt.Errorf("neither a .sql file nor a DSN: %v", err)
+	}
+	if len(cfg.DBName) == 0 {
+		return nil, "", fmt.Errorf("DSN of [%s] has no database, like user:password@tcp(127.0.0.1:3306)/d_user", cfg.Addr)
+	}
+	s, err := sql.Open("mysql", target)
+	if err != nil {
+		return nil, "", fmt.Errorf("open mysql err %v", err)
+	}
+	if err := s.Ping(); err != nil {
+		return nil, "", fmt.Errorf("connection Host [%s], happend error:%v", cfg.Addr, err)
+	}
+	return NewMySQLSource(goqu.New("mysql", s)), cfg.DBName, nil
+}
+
+// SchemaDiff is how the target schema differs from the source one
+type SchemaDiff struct {
+	MissingTables []*CreateTable // in the source only
+	ExtraTables   []*CreateTable // in the target only
+	Tables        []*TableDiff   // tables of both that differ, From is the target and To the source
+}
+
+// LoadTables reads the tables of database through DBInfo, or only table when it is not empty.
+// Tables whose DDL cannot be read or parsed, like views, are reported and skipped
+func LoadTables(source SchemaSource, database, table string) []*CreateTable {
+	info := NewInfo(source).FetchOriginTables(database)
+	names := info.ableTables
+	if len(table) > 0 {
+		names = []string{table}
+	}
+	var tables []*CreateTable
+	for _, name := range names {
+		qualified := name
+		if len(database) > 0 {
+			qualified = database + "." + name
+		}
+		info.FetchTableDDL(qualified)
+		if len(info.selectTableDDL) == 0 {
+			continue
+		}
+		s, err := ParseSchema(info.selectTableDDL)
+		if err != nil {
+			fmt.Printf("parse table [%s] err: %v\n", name, err)
+			continue
+		}
+		if len(s.Tables) > 0 {
+			tables = append(tables, s.Tables[0])
+		}
+	}
+	return tables
+}
+
+// DiffSchemas compares the tables of target with those of source, tables match by name
+// and the columns are compared with DiffTables, without rename hints
+func DiffSchemas(source, target []*CreateTable) *SchemaDiff {
+	d := &SchemaDiff{}
+	find := func(tables []*CreateTable, name string) *CreateTable {
+		for _, t := range tables {
+			if strings.EqualFold(t.Name, name) {
+				return t
+			}
+		}
+		return nil
+	}
+	for _, s := range source {
+		t := find(target, s.Name)
+		if t == nil {
+			d.MissingTables = append(d.MissingTables, s)
+			continue
+		}
+		if td := DiffTables(t, s, nil); !td.IsEmpty() {
+			d.Tables = append(d.Tables, td)
+		}
+	}
+	for _, t := range target {
+		if find(source, t.Name) == nil {
+			d.ExtraTables = append(d.ExtraTables, t)
+		}
+	}
+	return d
+}
+
+// IsEmpty reports whether both schemas are the same
+func (d *SchemaDiff) IsEmpty() bool {
+	return len(d.MissingTables)+len(d.ExtraTables)+len(d.Tables) == 0
+}
+
+// Report describes the differences, missing means in the source only and extra in the target only
+func (d *SchemaDiff) Report() string {
+	var b strings.Builder
+	line := func(format string, args ...interface{}) {
+		b.WriteString(fmt.Sprintf(format, args...) + "\n")
+	}
+	for _, t := range d.MissingTables {
+		line("missing table %s", t.Name)
+	}
+	for _, t := range d.ExtraTables {
+		line("extra table %s", t.Name)
+	}
+	for _, td := range d.Tables {
+		line("table %s:", td.Table)
+		for _, w := range td.Warnings {
+			line("  WARNING: %s", w)
+		}
+		for _, c := range td.AddColumns {
+			line("  missing column %s", c)
+		}
+		for _, c := range td.DropColumns {
+			line("  extra column %s", c)
+		}
+		for _, c := range td.RenameColumns {
+			line("  renamed column %s, target has %s", quoteIdent(c.To.Name), quoteIdent(c.From.Name))
+		}
+		for _, c := range td.ModifyColumns {
+			if c.From.String() == c.To.String() {
+				line("  moved column %s", quoteIdent(c.To.Name))
+				continue
+			}
+			line("  changed column %s", quoteIdent(c.To.Name))
+			line("    source: %s", c.To)
+			line("    target: %s", c.From)
+		}
+		for _, idx := range td.AddIndexes {
+			line("  missing index %s", idx)
+		}
+		for _, idx := range td.DropIndexes {
+			line("  extra index %s", idx)
+		}
+		for _, fk := range td.AddForeignKeys {
+			line("  missing foreign key %s", fk)
+		}
+		for _, fk := range td.DropForeignKeys {
+			line("  extra foreign key %s", fk)
+		}
+		for _, ck := range td.AddChecks {
+			line("  missing check %s", ck)
+		}
+		for _, ck := range td.DropChecks {
+			line("  extra check %s", ck)
+		}
+		for _, o := range td.Options {
+			line("  option %s: source %s, target %s", o.Name, valueOrNone(o.To), valueOrNone(o.From))
+		}
+		if td.Partition {
+			line("  partition: source %s, target %s", valueOrNone(td.To.Partition), valueOrNone(td.From.Partition))
+		}
+	}
+	return b.String()
+}
+
+func valueOrNone(v string) string {
+	if len(v) == 0 {
+		return "none"
+	}
+	return v
+}
+
+// Alter returns the statements turning the target into the source. The extra tables and
+// columns are only dropped in comments, so applying the script never loses data by accident
+func (d *SchemaDiff) Alter() []string {
+	var stmts []string
+	for _, t := range d.MissingTables {
+		stmts = append(stmts, t.withoutAutoIncrement().DDL()+";")
+	}
+	for _, td := range d.Tables {
+		for _, w := range td.Warnings {
+			stmts = append(stmts, "-- WARNING: "+w)
+		}
+		drop := fmt.Sprintf("ALTER TABLE %s DROP COLUMN ", quoteIdent(td.Table))
+		for _, stmt := range td.Up() {
+			if strings.HasPrefix(stmt, drop) {
+				stmt = "-- " + stmt
+			}
+			stmts = append(stmts, stmt)
+		}
+	}
+	for _, t := range d.ExtraTables {
+		stmts = append(stmts, fmt.Sprintf("-- DROP TABLE %s;", quoteIdent(t.Name)))
+	}
+	return stmts
+}
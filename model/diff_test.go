@@ -0,0 +1,27 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchemaDiffAlter(t *testing.T) {
+	source, err := ParseSchema("CREATE TABLE t_user (id int NOT NULL, name varchar(10) NOT NULL, PRIMARY KEY (id));")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := ParseSchema("CREATE TABLE t_user (id int NOT NULL, age int DEFAULT NULL, PRIMARY KEY (id));\n" +
+		"CREATE TABLE t_log (id int NOT NULL);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"-- WARNING: t_user: column age is dropped and name added at its position, add \"t_user.age\": \"name\" to renames in the config if it is a rename",
+		"-- ALTER TABLE `t_user` DROP COLUMN `age`;",
+		"ALTER TABLE `t_user` ADD COLUMN `name` varchar(10) NOT NULL AFTER `id`;",
+		"-- DROP TABLE `t_log`;",
+	}
+	if got := DiffSchemas(source.Tables, target.Tables).Alter(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Alter() = %q, want %q", got, want)
+	}
+}
@@ -3,114 +3,80 @@ package mysql
 import (
 	"bytes"
 	"fmt"
+	"go/ast"
 	"go/format"
-	"io"
+	"go/parser"
+	gotoken "go/token"
 	"os"
+	"sort"
 	"strings"
-	"time"
 
 	"github.com/golang/protobuf/protoc-gen-go/generator"
 )
 
 type Generate struct {
-	dbInfo       *DBInfo
-	tableInfo    *TableInfo
-	buf          *bytes.Buffer
-	imports      []string
-	constants    map[string]interface{} // key==>value
-	vars         map[string]interface{} // key==>value
-	columnFields []string
-	tableName    string
-	structName   string
-	afterFormat  []byte
+	dbInfo      *DBInfo
+	tableInfo   *TableInfo
+	buf         *bytes.Buffer
+	imports     []string
+	structName  string
+	softDelete  *softDelete
+	afterFormat []byte
 }
 
 func NewGenerate(dbInfo *DBInfo, tableInfo *TableInfo) *Generate {
 	return &Generate{
-		dbInfo:       dbInfo,
-		tableInfo:    tableInfo,
-		buf:          new(bytes.Buffer),
-		imports:      make([]string, 0, 10),
-		constants:    make(map[string]interface{}),
-		vars:         make(map[string]interface{}),
-		columnFields: make([]string, 0, 10),
+		dbInfo:    dbInfo,
+		tableInfo: tableInfo,
+		buf:       new(bytes.Buffer),
+		imports:   make([]string, 0, 10),
 	}
 }
 
+// NewGenerateFrom loads the table and its DDL through the source of dbInfo,
+// it returns nil when the table has no columns
+func NewGenerateFrom(dbInfo *DBInfo, tableName string) *Generate {
+	tableInfo := NewTableInfo(dbInfo.source).TableProfit(tableName)
+	if len(tableInfo.Fields) == 0 {
+		return nil
+	}
+	dbInfo.FetchTableDDL(tableName)
+	return NewGenerate(dbInfo, tableInfo)
+}
+
 func (g *Generate) Parse() *Generate {
 	if len(g.tableInfo.Fields) == 0 {
 		return g
 	}
 
-	name := g.dbInfo.selectTableName
-	if strings.Contains(name, "t_") {
-		name = strings.TrimPrefix(name, "t_")
-	}
-	g.tableName = "TableName"
-	g.structName = name
-
-	g.generateStruct()
-
-	g.generateGetOne()
-
-	g.generateGetOneWithFields()
-
-	g.generateSearch()
-
-	g.generateSearchWithFields()
-
-	g.generateSearchWithFieldsLimit()
-
-	g.generateCount()
-
-	g.generateCreate()
-
-	g.generateUpdate()
+	g.structName = baseName(g.dbInfo.selectTableName)
+	g.softDelete = g.tableInfo.softDeleteColumn()
 
-	g.generateImports()
-	g.generateConstant()
-	g.generateVars()
+	if err := g.render(); err != nil {
+		fmt.Println("template err:", err)
+		return g
+	}
 
 	g.format()
 
 	return g
 }
 
-func (g *Generate) generateCount() {
-	fd := `
-	func Count%s(ctx context.Context, exps interface{}) (int64, error) {
-	var count int64
-	var err error
-   conditions := exp.NewExpressionList(exp.AndType)
-	switch exps.(type) {
-	case map[string]interface{}:
-		for k, v := range exps.(map[string]interface{}) {
-			conditions = conditions.Append(goqu.I(k).Eq(v))
-		}
-	case exp.ExpressionList:
-		conditions = exps.(exp.ExpressionList)
-	case exp.Record:
-		for k, v := range exps.(exp.Record) {
-			conditions = conditions.Append(goqu.I(k).Eq(v))
-		}
-	}
-	count, err = db.GetInstance("read").From(%s).
-		Prepared(true).
-		Where(conditions).CountContext(ctx)
-	if err != nil {
-		return count, err
-	}
-
-	return count, nil
+func (g *Generate) getLowerName() string {
+	return packageName(g.structName)
 }
-	`
-	fd = fmt.Sprintf(fd, generator.CamelCase(g.structName), generator.CamelCase(g.tableName))
-	g.buf.WriteString(fd)
+
+// baseName is the table name the struct and the package are named after, without the t_ prefix
+func baseName(table string) string {
+	if strings.Contains(table, "t_") {
+		table = strings.TrimPrefix(table, "t_")
+	}
+	return table
 }
 
-func (g *Generate) getLowerName() string {
-	n := g.structName
-	n = strings.ToLower(n[0:1]) + n[1:]
+// packageName is the package of a table generated from its base name
+func packageName(name string) string {
+	n := strings.ToLower(name[0:1]) + name[1:]
 	n = strings.ReplaceAll(n, "_", "")
 	return n
 }
@@ -120,390 +86,180 @@ func (g *Generate) getPackageName() []byte {
 	return []byte("package " + n + "\n\n")
 }
 
-func (g *Generate) generateStruct() {
-	s := fmt.Sprintf("type %s struct {\n  \n", generator.CamelCase(g.structName))
-	g.buf.WriteString(s)
-	timeIsFound := false
-	for _, f := range g.tableInfo.Fields {
-		filedName := generator.CamelCase(f.Field)
-		jsonName := strings.ToLower(filedName[0:1]) + filedName[1:]
-		goqu := g.tableInfo.ConvertGoQu(f)
-		g.columnFields = append(g.columnFields, f.Field)
-		filedType := g.tableInfo.ConvertType(f)
-		if filedType == "time.Time" && !timeIsFound {
-			g.imports = append(g.imports, "time")
-			timeIsFound = true
+// enumConstName turns an enum value into an exported identifier suffix
+func enumConstName(v string) string {
+	b := []byte(v)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			b[i] = '_'
 		}
-		s := fmt.Sprintf("%s\t%s\t `db:\"%s\" json:\"%s,omitempty\" goqu:\"%s\"`  // %s\n",
-			filedName, filedType, f.Field, jsonName, goqu, strings.Trim(f.Comment, " "))
-
-		g.buf.WriteString(s)
 	}
-	g.buf.WriteString("}")
-}
-
-func (g *Generate) generateCreate() {
-	fd := `
-	func Create%s(ctx context.Context,%s *%s,tx *goqu.TxDatabase,excludeFields ...string) (int64,error){
-	var builder *goqu.InsertDataset
-	if tx != nil {
-		builder = tx.Insert(%s)
-	} else {
-		builder = db.GetInstance("").Insert(%s)
+	name := generator.CamelCase(strings.Trim(string(b), "_"))
+	if len(name) == 0 {
+		return "Empty"
 	}
-	cols := make([]interface{}, 0, 10)
-	if len(excludeFields) > 0 {
-		_tempMap := make(map[string]struct{})
-		for _, e := range excludeFields {
-			_tempMap[e] = struct{}{}
-		}
-		for _, s := range ColumnFields {
-			_s := s.(string)
-			if _, ok := _tempMap[_s]; !ok {
-				cols = append(cols, s)
-			}
-		}
-	} else {
-		cols = ColumnFields
-	}
-   b, err := builder.Prepared(true).
-		Cols(cols...).
-		Rows(%s).
-		Executor().ExecContext(ctx)
-	if err != nil {
-		return 0, err
+	if name[0] >= '0' && name[0] <= '9' {
+		return "V" + name
 	}
-
-	return b.LastInsertId()
+	return name
 }
-`
 
-	firsS := strings.ToLower(generator.CamelCase(g.structName)[0:1])
-	fd = fmt.Sprintf(fd, generator.CamelCase(g.structName), firsS, generator.CamelCase(g.structName), generator.CamelCase(g.tableName), generator.CamelCase(g.tableName), firsS)
-	g.buf.WriteString(fd)
+// typeImports maps the package qualifier of a field type to its import path
+var typeImports = map[string]string{
+	"time":      "time",
+	"sql":       "database/sql",
+	"json":      "encoding/json",
+	"decimal":   "github.com/shopspring/decimal",
+	"modelutil": "github.com/lights-T/mysql_generate/modelutil",
 }
 
-func (g *Generate) generateUpdate() {
-	fd := `
-      func Update%s(ctx context.Context,data map[string]interface{},exps interface{},tx *goqu.TxDatabase) (int64, error){
-	var builder *goqu.UpdateDataset
-	if tx != nil {
-		builder = tx.Update(%s)
-	} else {
-		builder = db.GetInstance("").Update(%s)
-	}
-    rc := make(goqu.Record)
-	for k,v := range data{
-		rc[k]=v
-	}
-    conditions := exp.NewExpressionList(exp.AndType)
-	switch exps.(type) {
-	case map[string]interface{}:
-		for k, v := range exps.(map[string]interface{}) {
-			conditions = conditions.Append(goqu.I(k).Eq(v))
-		}
-	case exp.ExpressionList:
-		conditions = exps.(exp.ExpressionList)
-	case exp.Record:
-		for k, v := range exps.(exp.Record) {
-			conditions = conditions.Append(goqu.I(k).Eq(v))
-		}
-	}
-    u,err := builder.Set(rc).Where(conditions).Executor().ExecContext(ctx)
-	if err !=nil {
-		return 0,err
+// registerType turns a type with its import path like github.com/x/types.JSON into
+// types.JSON and remembers the import, types without a path are returned as is
+func registerType(typ string) string {
+	prefix := strings.TrimRight(typ, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_./-")
+	spec := typ[len(prefix):]
+	i := strings.LastIndex(spec, "/")
+	if i < 0 {
+		return typ
+	}
+	j := strings.Index(spec[i:], ".")
+	if j < 0 {
+		return typ
+	}
+	path, name := spec[:i+j], spec[i+j+1:]
+	qualifier := path[strings.LastIndex(path, "/")+1:]
+	typeImports[qualifier] = path
+	return prefix + qualifier + "." + name
 }
 
- return u.RowsAffected()
-}
-`
-	fd = fmt.Sprintf(fd, generator.CamelCase(g.structName), generator.CamelCase(g.tableName), generator.CamelCase(g.tableName))
-	g.buf.WriteString(fd)
+// importOfType returns the import path a field type needs, like database/sql for *sql.NullString
+func importOfType(typ string) string {
+	typ = strings.TrimLeft(typ, "*[]")
+	if i := strings.Index(typ, "."); i > 0 {
+		return typeImports[typ[:i]]
+	}
+	return ""
 }
 
-func (g *Generate) generateGetOne() {
-	fd := `
-    // Get%s exps 支持 map[string]interface{} 或 goqu 表达式（eq: exp.NewExpressionList(exp.AndType).Append(goqu.C(k).Eq(v))）
-	func Get%s(ctx context.Context, exps interface{}, excludeFields ...string) (*%s, error) {
-	self := &%s{}
-    cols := make([]interface{}, 0, len(ColumnFields))
-	if len(excludeFields) > 0 {
-		_tempMap := make(map[string]struct{})
-		for _, e := range excludeFields {
-			_tempMap[e] = struct{}{}
-		}
-		for _, s := range ColumnFields {
-            _s := s.(string)
-			if _, ok := _tempMap[_s]; !ok {
-				cols = append(cols, s)
-			}
-		}
-	} else {
-		cols = ColumnFields
+// addImport adds an import path once, empty paths are ignored
+func (g *Generate) addImport(path string) {
+	if len(path) == 0 {
+		return
 	}
-    conditions := exp.NewExpressionList(exp.AndType)
-	switch exps.(type) {
-	case map[string]interface{}:
-		for k, v := range exps.(map[string]interface{}) {
-			conditions = conditions.Append(goqu.I(k).Eq(v))
-		}
-	case exp.ExpressionList:
-		conditions = exps.(exp.ExpressionList)
-	case exp.Record:
-		for k, v := range exps.(exp.Record) {
-			conditions = conditions.Append(goqu.I(k).Eq(v))
+	for _, i := range g.imports {
+		if i == path {
+			return
 		}
 	}
-	if _, err := db.GetInstance("read").From(%s).
-		Prepared(true).
-		Select(cols...).
-		Where(conditions).
-		ScanStructContext(ctx, self); err != nil {
-		return nil, err
-	}
-
-	return self, nil
-}
-	`
-	fd = fmt.Sprintf(fd, generator.CamelCase(g.structName), generator.CamelCase(g.structName), generator.CamelCase(g.structName), generator.CamelCase(g.structName), generator.CamelCase(g.tableName))
-	g.buf.WriteString(fd)
+	g.imports = append(g.imports, path)
 }
 
-func (g *Generate) generateGetOneWithFields() {
-	fd := `
-	func Get%sWithFields(ctx context.Context, exps interface{}, includeFields ...string) (*%s, error) {
-	self := &%s{}
-    cols := make([]interface{}, 0, len(ColumnFields))
-	if len(includeFields) > 0 {
-		_tempMap := make(map[string]struct{})
-		for _, e := range includeFields {
-			_tempMap[e] = struct{}{}
-		}
-		for _, s := range ColumnFields {
-            _s := s.(string)
-			if _, ok := _tempMap[_s]; ok {
-				cols = append(cols, s)
-			}
-		}
-
-	} else {
-		cols = ColumnFields
-	}
-   conditions := exp.NewExpressionList(exp.AndType)
-	switch exps.(type) {
-	case map[string]interface{}:
-		for k, v := range exps.(map[string]interface{}) {
-			conditions = conditions.Append(goqu.I(k).Eq(v))
-		}
-	case exp.ExpressionList:
-		conditions = exps.(exp.ExpressionList)
-	case exp.Record:
-		for k, v := range exps.(exp.Record) {
-			conditions = conditions.Append(goqu.I(k).Eq(v))
-		}
-	}
-	if _, err := db.GetInstance("read").From(%s).
-		Prepared(true).
-		Select(cols...).
-		Where(conditions).
-		ScanStructContext(ctx, self); err != nil {
-		return nil, err
+// isOrderedType reports whether BETWEEN makes sense for the column
+func isOrderedType(f *FieldInfo, typ string) bool {
+	switch parseColumnType(f.Type).Name {
+	case "date", "datetime", "timestamp", "time", "year":
+		return true
 	}
+	return strings.HasPrefix(typ, "int") || strings.HasPrefix(typ, "uint") || strings.HasPrefix(typ, "float") ||
+		strings.HasSuffix(typ, "Decimal") || typ == "time.Time"
+}
 
-	return self, nil
+// goKeywords cannot be used as parameter names
+var goKeywords = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true, "default": true,
+	"defer": true, "else": true, "fallthrough": true, "for": true, "func": true, "go": true, "goto": true,
+	"if": true, "import": true, "interface": true, "map": true, "package": true, "range": true,
+	"return": true, "select": true, "struct": true, "switch": true, "type": true, "var": true,
 }
-	`
-	fd = fmt.Sprintf(fd, generator.CamelCase(g.structName), generator.CamelCase(g.structName), generator.CamelCase(g.structName), generator.CamelCase(g.tableName))
-	g.buf.WriteString(fd)
+
+// reservedParams are the other parameters, the locals, the packages and the helpers the templates
+// use next to the column parameters
+var reservedParams = map[string]bool{
+	"ctx": true, "limit": true, "opts": true, "excludeFields": true, "self": true, "cols": true,
+	"q": true, "query": true, "args": true, "err": true, "conditions": true, "exps": true,
+	"rows": true, "row": true, "keys": true, "seen": true, "ref": true, "k": true, "n": true,
+	"v": true, "vs": true, "from": true, "to": true,
+	"selected": true, "cond": true, "where": true, "bound": true, "batch": true,
+	"db": true, "exp": true, "goqu": true, "modelutil": true, "context": true,
+	"prepareRead": true, "scopeDeleted": true,
 }
 
-func (g *Generate) generateSearch() {
-	fd := `
-	func Search%s(ctx context.Context, exps interface{}, excludeFields ...string) ([]*%s, error) {
-	var self  []*%s
-    cols := make([]interface{}, 0, len(ColumnFields))
-	if len(excludeFields) > 0 {
-		_tempMap := make(map[string]struct{})
-		for _, e := range excludeFields {
-			_tempMap[e] = struct{}{}
-		}
-		for _, s := range ColumnFields {
-			_s := s.(string)
-			if _, ok := _tempMap[_s]; !ok {
-				cols = append(cols, s)
-			}
-		}
-	} else {
-		cols = ColumnFields
-	}
-   conditions := exp.NewExpressionList(exp.AndType)
-	switch exps.(type) {
-	case map[string]interface{}:
-		for k, v := range exps.(map[string]interface{}) {
-			conditions = conditions.Append(goqu.I(k).Eq(v))
-		}
-	case exp.ExpressionList:
-		conditions = exps.(exp.ExpressionList)
-	case exp.Record:
-		for k, v := range exps.(exp.Record) {
-			conditions = conditions.Append(goqu.I(k).Eq(v))
-		}
-	}
-	if  err := db.GetInstance("read").From(%s).
-		Prepared(true).
-		Select(cols...).
-		Where(conditions).
-        Limit(MaxLimit).
-		ScanStructsContext(ctx, &self); err != nil {
-		return nil, err
-	}
-	if len(self) == 0 {
-		return nil, nil
+// paramName turns a column into a lower camel parameter name, names taken by a keyword
+// or by the templates get a Value suffix
+func paramName(column string) string {
+	n := generator.CamelCase(column)
+	n = strings.ToLower(n[0:1]) + n[1:]
+	if goKeywords[n] || reservedParams[n] {
+		return n + "Value"
 	}
-
-	return self, nil
-}
-	`
-	fd = fmt.Sprintf(fd, generator.CamelCase(g.structName), generator.CamelCase(g.structName), generator.CamelCase(g.structName), generator.CamelCase(g.tableName))
-	g.buf.WriteString(fd)
+	return n
 }
 
-func (g *Generate) generateSearchWithFields() {
-	fd := `
-	func Search%sWithFields(ctx context.Context, exps interface{}, includeFields ...string) ([]*%s, error) {
-	var self  []*%s
-    cols := make([]interface{}, 0, len(ColumnFields))
-	if len(includeFields) > 0 {
-		_tempMap := make(map[string]struct{})
-		for _, e := range includeFields {
-			_tempMap[e] = struct{}{}
-		}
-		for _, s := range ColumnFields {
-			_s := s.(string)
-			if _, ok := _tempMap[_s]; ok {
-				cols = append(cols, s)
+// cursorOrders returns the orderings Search<Struct>After accepts keyed by the index
+// columns, with the primary key appended to a non unique index to break ties. Only
+// indexes on NOT NULL columns of JSON friendly types qualify, the default is the first one
+func (g *Generate) cursorOrders() ([]string, map[string][]string) {
+	var pk []string
+	for _, idx := range g.tableInfo.Indexes {
+		if idx.Primary {
+			pk = idx.Columns
+		}
+	}
+	var keys []string
+	orders := make(map[string][]string)
+	for _, idx := range g.tableInfo.Indexes {
+		if len(idx.Columns) == 0 || idx.Type == "FULLTEXT" || idx.Type == "SPATIAL" {
+			continue
+		}
+		order := append([]string(nil), idx.Columns...)
+		ok := true
+		for _, c := range idx.Columns {
+			f := g.tableInfo.Field(c)
+			if f == nil || f.IsNullable() {
+				ok = false
+				break
+			}
+			typ := g.tableInfo.FieldType(f)
+			if strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "map[") || typ == "json.RawMessage" ||
+				parseColumnType(f.Type).Name == "json" {
+				ok = false
+				break
 			}
 		}
-	} else {
-		cols = ColumnFields
-	}
-    conditions := exp.NewExpressionList(exp.AndType)
-	switch exps.(type) {
-	case map[string]interface{}:
-		for k, v := range exps.(map[string]interface{}) {
-			conditions = conditions.Append(goqu.I(k).Eq(v))
-		}
-	case exp.ExpressionList:
-		conditions = exps.(exp.ExpressionList)
-	case exp.Record:
-		for k, v := range exps.(exp.Record) {
-			conditions = conditions.Append(goqu.I(k).Eq(v))
+		if !ok {
+			continue
 		}
-	}
-	if  err := db.GetInstance("read").From(%s).
-		Prepared(true).
-		Select(cols...).
-		Where(conditions).
-         Limit(MaxLimit).
-		ScanStructsContext(ctx, &self); err != nil {
-		return nil, err
-	}
-	if len(self) == 0 {
-		return nil, nil
-	}
-
-	return self, nil
-}
-	`
-	fd = fmt.Sprintf(fd, generator.CamelCase(g.structName), generator.CamelCase(g.structName), generator.CamelCase(g.structName), generator.CamelCase(g.tableName))
-	g.buf.WriteString(fd)
-}
-
-func (g *Generate) generateSearchWithFieldsLimit() {
-	fd := `
-	func Search%sWithFieldsLimit(ctx context.Context, exps interface{},offset,limit uint, includeFields ...string) ([]*%s, error) {
-	var self  []*%s
-    cols := make([]interface{}, 0, len(ColumnFields))
-    if limit > MaxLimit {
-        limit = MaxLimit
-     }
-	if len(includeFields) > 0 {
-		_tempMap := make(map[string]struct{})
-		for _, e := range includeFields {
-			_tempMap[e] = struct{}{}
-		}
-		for _, s := range ColumnFields {
-			_s := s.(string)
-			if _, ok := _tempMap[_s]; ok {
-				cols = append(cols, s)
+		if !idx.Unique {
+			if len(pk) == 0 {
+				continue
+			}
+			for _, c := range pk {
+				if !contains(order, c) {
+					order = append(order, c)
+				}
 			}
 		}
-	} else {
-		cols = ColumnFields
-	}
-   conditions := exp.NewExpressionList(exp.AndType)
-	switch exps.(type) {
-	case map[string]interface{}:
-		for k, v := range exps.(map[string]interface{}) {
-			conditions = conditions.Append(goqu.I(k).Eq(v))
-		}
-	case exp.ExpressionList:
-		conditions = exps.(exp.ExpressionList)
-	case exp.Record:
-		for k, v := range exps.(exp.Record) {
-			conditions = conditions.Append(goqu.I(k).Eq(v))
+		key := strings.Join(idx.Columns, ",")
+		if _, ok := orders[key]; ok {
+			continue
 		}
+		keys = append(keys, key)
+		orders[key] = order
+		orders[strings.Join(order, ",")] = order
 	}
-	if  err := db.GetInstance("read").From(%s).
-		Prepared(true).
-		Select(cols...).
-		Where(conditions).
-        Offset(offset).
-        Limit(limit).
-		ScanStructsContext(ctx, &self); err != nil {
-		return nil, err
-	}
-	if len(self) == 0 {
+	if len(keys) == 0 {
 		return nil, nil
 	}
-
-	return self, nil
+	return strings.Split(keys[0], ","), orders
 }
-	`
-	fd = fmt.Sprintf(fd, generator.CamelCase(g.structName), generator.CamelCase(g.structName), generator.CamelCase(g.structName), generator.CamelCase(g.tableName))
-	g.buf.WriteString(fd)
-}
-
-func (g *Generate) generateImports() {
-	imports := []string{
-		"context",
-		fmt.Sprint(""),
-		"github.com/doug-martin/goqu/v9",
-		"github.com/doug-martin/goqu/v9/exp",
-		fmt.Sprint(""),
-		fmt.Sprintf("db %s", Package),
-	}
-	g.imports = append(g.imports, imports...)
-}
-
-func (g *Generate) generateConstant() {
-
-	// 定义表名
-	g.constants[generator.CamelCase(g.tableName)] = fmt.Sprintf("\"%s\"", g.dbInfo.selectTableName)
-
-	// limit限制
-	g.constants["MaxLimit"] = 1000
 
-}
-
-func (g *Generate) generateVars() {
-	// 定义所有的字段
-	fstr := ""
-	for _, f := range g.columnFields {
-		fstr += fmt.Sprintf("\"%s\",", f)
+func contains(strs []string, s string) bool {
+	for _, e := range strs {
+		if e == s {
+			return true
+		}
 	}
-	fstr = strings.Trim(fstr, ",")
-	g.vars["ColumnFields"] = fmt.Sprintf("[]interface{}{%s}", fstr)
+	return false
 }
 
 func (g *Generate) format() {
@@ -512,36 +268,32 @@ func (g *Generate) format() {
 	// package
 	b.Write(g.getPackageName())
 
-	// import
+	// import, grouped as standard library, third party and the model package
 	if len(g.imports) > 0 {
-		b.WriteString("import (\n")
+		var std, third, local []string
 		for _, s := range g.imports {
-			if len(s) == 0 {
-				b.WriteString("\n")
-				continue
-			}
+			spec := "\"" + s + "\""
+			path := s
 			if arr := strings.Split(s, " "); len(arr) > 1 {
-				b.WriteString(fmt.Sprintf("%s \"%s\"", arr[0], arr[1]))
-			} else {
-				b.WriteString("\"" + s + "\"\n")
+				spec = fmt.Sprintf("%s \"%s\"", arr[0], arr[1])
+				path = arr[1]
+			}
+			switch {
+			case len(Package) > 0 && strings.HasPrefix(path, Package):
+				local = append(local, spec)
+			case !strings.Contains(strings.Split(path, "/")[0], "."):
+				std = append(std, spec)
+			default:
+				third = append(third, spec)
 			}
 		}
-		b.WriteString(")\n")
-	}
-	// constant
-	if len(g.constants) > 0 {
-		b.WriteString("const (\n")
-		for k, v := range g.constants {
-			b.WriteString(fmt.Sprintf("%s=%v\n", k, v))
-		}
-		b.WriteString(")\n")
-	}
-
-	// variables
-	if len(g.vars) > 0 {
-		b.WriteString("var (\n")
-		for k, v := range g.vars {
-			b.WriteString(fmt.Sprintf("%s=%v\n", k, v))
+		b.WriteString("import (\n")
+		for _, group := range [][]string{std, third, local} {
+			if len(group) == 0 {
+				continue
+			}
+			sort.Strings(group)
+			b.WriteString(strings.Join(group, "\n") + "\n\n")
 		}
 		b.WriteString(")\n")
 	}
@@ -554,10 +306,52 @@ func (g *Generate) format() {
 		fmt.Println("format err:", err)
 		return
 	}
+	// a table, a column or a template can make two declarations share a name
+	if names := declaredTwice(by); len(names) > 0 {
+		fmt.Printf("table [%s] declares %s more than once, rename the table, the column or the template\n",
+			g.dbInfo.selectTableName, strings.Join(names, ", "))
+		return
+	}
 
 	g.afterFormat = by
 }
 
+// declaredTwice returns the package level names src declares more than once, in name order
+func declaredTwice(src []byte) []string {
+	f, err := parser.ParseFile(gotoken.NewFileSet(), "", src, 0)
+	if err != nil {
+		return nil
+	}
+	seen := make(map[string]int)
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil {
+				seen[d.Name.Name]++
+			}
+		case *ast.GenDecl:
+			for _, s := range d.Specs {
+				switch s := s.(type) {
+				case *ast.TypeSpec:
+					seen[s.Name.Name]++
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						seen[n.Name]++
+					}
+				}
+			}
+		}
+	}
+	var names []string
+	for n, c := range seen {
+		if c > 1 && n != "_" {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (g *Generate) Write() error {
 	_dir, err := os.Getwd()
 	if err != nil {
@@ -568,6 +362,10 @@ func (g *Generate) Write() error {
 		return fmt.Errorf("Current path [%s], Must come into the model dir execute ", _dir)
 	}
 
+	if len(g.afterFormat) == 0 {
+		return fmt.Errorf("table [%s] generated nothing", g.dbInfo.selectTableName)
+	}
+
 	pfn := g.getLowerName()
 	dir := _dir + "/" + pfn
 	if _, _err := os.Stat(dir); _err != nil {
@@ -597,154 +395,3 @@ func (g *Generate) Write() error {
 func (g *Generate) String() string {
 	return string(g.afterFormat)
 }
-
-func (g *Generate) WriteDDL() error {
-
-	_dir, err := os.Getwd()
-	if err != nil {
-		return err
-	}
-	if arr := strings.Split(_dir, "/"); arr[len(arr)-1] != "model" {
-
-		return fmt.Errorf("Current path [%s], Must come into the model dir execute ", _dir)
-	}
-
-	arr := strings.Split(_dir, "/")
-	createDirs := append(arr[:len(arr)-1], "doc")
-	createDir := strings.Join(createDirs, "/")
-	dir := createDir + "/" + strings.ToLower(g.structName[0:1]) + g.structName[1:]
-
-	var readDDL string
-	if _, _err := os.Stat(dir); _err != nil {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return err
-		}
-	}
-	file := dir + "/" + strings.ToLower(g.structName[0:1]) + g.structName[1:] + ".sql"
-	var f *os.File
-	if _, err := os.Stat(file); os.IsNotExist(err) {
-		f, err = os.Create(file)
-		if err != nil {
-			return err
-		}
-	} else {
-		f, err = os.OpenFile(file, os.O_RDWR|os.O_CREATE, 0755)
-		if err != nil {
-			return err
-		}
-		var content []byte
-		temp := make([]byte, 1024)
-		for {
-			n, err := f.Read(temp)
-			if err == io.EOF {
-				break
-			}
-			content = append(content, temp[:n]...)
-		}
-		if strings.Contains(string(content), "Create@") {
-			arr := strings.Split(string(content), "Create@")
-			readDDL = arr[len(arr)-1]
-		}
-	}
-	defer f.Close()
-
-	var b bytes.Buffer
-	host, _ := os.Hostname()
-	b.WriteString(fmt.Sprintf("# Create@%s,By: %s\n", time.Now().Format("2006-01-02 15:04:05"), host))
-
-	// content
-	b.Write([]byte(fmt.Sprintf("create table if not exists %s\n", g.tableInfo.TableName)))
-	b.Write([]byte(g.dbInfo.selectTableDDL[strings.Index(g.dbInfo.selectTableDDL, "("):]))
-	b.WriteString(";\n")
-	b.WriteString("# =====================================================================================\n")
-	sb := b.Bytes()
-
-	if len(readDDL) == 0 {
-		_, err = f.Write(sb)
-		fmt.Println("Create [" + file + "] Success")
-		return err
-	}
-
-	arr2 := strings.Split(string(sb), "Create@")
-	readFieldStr := readDDL[strings.Index(readDDL, "("):strings.LastIndex(readDDL, "ENGINE")]
-	writeFieldStr := arr2[1][strings.Index(arr2[1], "("):strings.LastIndex(arr2[1], "ENGINE")]
-	if readFieldStr != writeFieldStr {
-		readDDLFields := strings.Split(readFieldStr, "\n")
-		writeDDLFields := strings.Split(writeFieldStr, "\n")
-		var addFields []string
-		var removeFields []string
-		var modifyFields []string
-		for _, w := range writeDDLFields {
-			if !strings.Contains(readFieldStr, w) {
-				if strings.Contains(readFieldStr, w[strings.Index(w, "`"):strings.LastIndex(w, "`")]) {
-					modifyFields = append(modifyFields, w)
-					continue
-				}
-				addFields = append(addFields, w)
-			}
-		}
-
-		for _, r := range readDDLFields {
-			if !strings.Contains(writeFieldStr, r) {
-				if strings.Contains(writeFieldStr, r[strings.Index(r, "`"):strings.LastIndex(r, "`")]) {
-					continue
-				}
-				removeFields = append(removeFields, r)
-			}
-		}
-		var updateByte bytes.Buffer
-		for _, a := range addFields {
-			a = strings.TrimPrefix(a, " ")
-			if strings.Contains(a, "UNIQUE KEY") {
-				updateByte.WriteString(fmt.Sprintf("CREATE UNIQUE INDEX `%s` ON %s %s;\n", strings.Split(a, "`")[1],
-					g.tableInfo.TableName, a[strings.Index(a, "("):strings.LastIndex(a, ")")+1]))
-				continue
-			} else if strings.Contains(a, "PRIMARY KEY") {
-				updateByte.WriteString(fmt.Sprintf("ALTER  TABLE %s ADD  PRIMARY  KEY %s;\n",
-					g.tableInfo.TableName, a[strings.Index(a, "("):strings.LastIndex(a, ")")+1]))
-				continue
-			} else if strings.Contains(a, " KEY") {
-				updateByte.WriteString(fmt.Sprintf("CREATE  INDEX `%s` ON %s %s;\n", strings.Split(a, "`")[1],
-					g.tableInfo.TableName, a[strings.Index(a, "("):strings.LastIndex(a, ")")+1]))
-				continue
-			} else {
-				updateByte.WriteString(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;\n", g.tableInfo.TableName,
-					strings.Replace(a, ",", "", -1)))
-			}
-
-		}
-		for _, r := range removeFields {
-			r = strings.TrimPrefix(r, " ")
-			if strings.Contains(r, " KEY") {
-				updateByte.WriteString(fmt.Sprintf("DROP  INDEX %s ON %s;\n", strings.Split(r, "`")[1],
-					g.tableInfo.TableName))
-				continue
-			} else {
-				updateByte.WriteString(fmt.Sprintf("ALTER TABLE %s DROP COLUMN `%s`;\n", g.tableInfo.TableName,
-					strings.Split(r, "`")[1]))
-			}
-		}
-		for _, m := range modifyFields {
-			m = strings.TrimPrefix(m, " ")
-			if !strings.Contains(m, "KEY") {
-				updateByte.WriteString(fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s;\n", g.tableInfo.TableName,
-					strings.Replace(m, ",", "", -1)))
-			}
-
-		}
-		var title bytes.Buffer
-		host, _ := os.Hostname()
-		title.WriteString("\n")
-		title.WriteString(fmt.Sprintf("# Create@%s,By: %s\n", time.Now().Format("2006-01-02 15:04:05"), host))
-		_, err = f.Write(title.Bytes())
-		_, err = f.Write([]byte(fmt.Sprintf("create table if not exists %s\n", g.tableInfo.TableName)))
-		_, err = f.Write([]byte(g.dbInfo.selectTableDDL[strings.Index(g.dbInfo.selectTableDDL, "("):]))
-		_, err = f.Write([]byte(";\n# change：\n"))
-		updateByte.WriteString("# =====================================================================================\n")
-		_, err = f.Write(updateByte.Bytes())
-		fmt.Println("Update [" + file + "] Success")
-		return err
-	}
-
-	return nil
-}
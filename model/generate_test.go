@@ -0,0 +1,225 @@
+package mysql
+
+import (
+	"bytes"
+	"fmt"
+	"go/parser"
+	gotoken "go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fixtureSchema is generated by TestGenerateFromMemorySource table by table
+const fixtureSchema = `
+CREATE TABLE t_order (
+  order_id int NOT NULL AUTO_INCREMENT,
+  user_id bigint unsigned NOT NULL,
+  amount decimal(10,2) NOT NULL,
+  note varchar(10) DEFAULT NULL,
+  paid_at datetime DEFAULT NULL,
+  deleted_at datetime DEFAULT NULL,
+  PRIMARY KEY (order_id),
+  UNIQUE KEY uk_user_order (user_id, order_id),
+  KEY idx_paid (paid_at),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES t_user (id)
+);
+
+CREATE TABLE t_columns (
+  id int NOT NULL,
+  repo enum('git','svn') NOT NULL,
+  columns enum('a','b') DEFAULT NULL,
+  PRIMARY KEY (id)
+);
+
+CREATE TABLE t_bill (id int NOT NULL, PRIMARY KEY (id));
+CREATE TABLE t_lock (id int NOT NULL, PRIMARY KEY (id));
+CREATE TABLE t_order_by (id int NOT NULL, PRIMARY KEY (id));
+CREATE TABLE t_query_options (id int NOT NULL, PRIMARY KEY (id));
+
+CREATE TABLE t_decimal (
+  id int NOT NULL,
+  amount decimal(12,4) DEFAULT NULL,
+  PRIMARY KEY (id)
+);
+
+CREATE TABLE t_names (
+  id int NOT NULL,
+  opts int NOT NULL,
+  query varchar(10) NOT NULL,
+  err int NOT NULL,
+  due_at datetime DEFAULT NULL,
+  PRIMARY KEY (id),
+  KEY idx_opts_query (opts, query),
+  KEY idx_err (err),
+  KEY idx_due (due_at)
+);
+`
+
+// fixtureSource is t_user built by hand, the way a test feeds the generator without
+// a schema file, plus the tables of fixtureSchema
+func fixtureSource(t *testing.T) *MemorySource {
+	s, err := ParseSchema(fixtureSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMemorySource()
+	str := func(s string) *string { return &s }
+	m.AddTable("t_user", []*FieldInfo{
+		{Field: "id", Type: "bigint unsigned", Null: "NO", Key: "PRI", Extra: str("auto_increment")},
+		{Field: "user_name", Type: "varchar(64)", Null: "NO", Key: "UNI", Default: str("")},
+		{Field: "status", Type: "enum('active','banned')", Null: "NO", Key: "MUL", Default: str("active")},
+		{Field: "age", Type: "tinyint unsigned", Null: "YES", Key: "MUL"},
+		{Field: "profile", Type: "json", Null: "YES"},
+		{Field: "create_time", Type: "timestamp", Null: "NO", Default: str("CURRENT_TIMESTAMP"), Extra: str("DEFAULT_GENERATED")},
+	}, []*IndexInfo{
+		{KeyName: "PRIMARY", ColumnName: "id", SeqInIndex: 1, IndexType: "BTREE"},
+		{KeyName: "uk_name", ColumnName: "user_name", SeqInIndex: 1, IndexType: "BTREE"},
+		{KeyName: "idx_status_age", ColumnName: "status", NonUnique: 1, SeqInIndex: 1, IndexType: "BTREE"},
+		{KeyName: "idx_status_age", ColumnName: "age", NonUnique: 1, SeqInIndex: 2, IndexType: "BTREE"},
+	}, "CREATE TABLE `t_user` (\n  `id` bigint unsigned NOT NULL AUTO_INCREMENT\n)")
+	for _, ct := range s.Tables {
+		m.AddTable(ct.Name, ct.Fields(), ct.IndexInfos(), ct.DDL(), ct.ForeignKeyInfos()...)
+	}
+	return m
+}
+
+// buildModels writes the generated packages with db.go into a module replacing this one by root
+// and builds it, the output has to compile and not only parse
+func buildModels(t *testing.T, root string, packages map[string]string) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	if templates == nil {
+		if err := LoadTemplates(""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var db bytes.Buffer
+	if err := templates.ExecuteTemplate(&db, "db.tmpl", struct{ Package string }{"model"}); err != nil {
+		t.Fatal(err)
+	}
+	sum, err := ioutil.ReadFile(filepath.Join(root, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": fmt.Sprintf("module example.com/app\n\ngo 1.16\n\nrequire github.com/lights-T/mysql_generate v0.0.0\n\n"+
+			"replace github.com/lights-T/mysql_generate => %s\n", root),
+		"go.sum":      string(sum),
+		"model/db.go": db.String(),
+	}
+	for pkg, src := range packages {
+		files[filepath.Join("model", pkg, pkg+".go")] = src
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command("go", "build", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+}
+
+func TestGenerateFromMemorySource(t *testing.T) {
+	defer func(o *Options, p string) { options, Package = o, p }(options, Package)
+	Package = "example.com/app/model"
+
+	cases := []struct {
+		name    string
+		options Options
+	}{
+		{"plain", Options{}},
+		{"pointer", Options{Null: NullPointer, Time: true, Decimal: DecimalString}},
+		{"sql", Options{Null: NullSQL, Time: true, TinyIntBool: true, Decimal: DecimalLocal}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			root, err := filepath.Abs("..")
+			if err != nil {
+				t.Fatal(err)
+			}
+			o := c.options
+			SaveOptions(&o)
+			source := fixtureSource(t)
+			info := NewInfo(source)
+			tables, _ := source.Tables("")
+			packages := make(map[string]string)
+			for _, table := range tables {
+				info.selectTableName = table
+				g := NewGenerateFrom(info, table)
+				if g == nil {
+					t.Fatalf("%s: no columns", table)
+				}
+				src := g.Parse().String()
+				if len(src) == 0 {
+					t.Fatalf("%s: generated nothing, see the output above", table)
+				}
+				if _, err := parser.ParseFile(gotoken.NewFileSet(), table+".go", src, 0); err != nil {
+					t.Fatalf("%s: %v\n%s", table, err, src)
+				}
+				if names := declaredTwice([]byte(src)); len(names) > 0 {
+					t.Errorf("%s: declared twice: %v", table, names)
+				}
+				packages[g.getLowerName()] = src
+			}
+			buildModels(t, root, packages)
+		})
+	}
+}
+
+func TestGenerateSkipsLoaderOfMissingPackage(t *testing.T) {
+	defer func(o *Options, p string, c *Connection) { options, Package, conInfo = o, p, c }(options, Package, conInfo)
+	Package = "example.com/app/model"
+	SaveOptions(&Options{})
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	generate := func(table string) string {
+		source := fixtureSource(t)
+		info := NewInfo(source)
+		info.selectTableName = table
+		return NewGenerateFrom(info, table).Parse().String()
+	}
+	conInfo = &Connection{T: "t_order"}
+	order := generate("t_order")
+	if strings.Contains(order, "func LoadUserForOrders") || !strings.Contains(order, "func ListOrdersByUserId") {
+		t.Errorf("t_user is not generated, want ListOrdersByUserId only")
+	}
+	buildModels(t, filepath.Dir(wd), map[string]string{"order": order})
+
+	// an earlier run generated t_user
+	conInfo = &Connection{T: "t_user"}
+	user := generate("t_user")
+	if err := os.MkdirAll(filepath.Join(dir, "user"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "user", "user.go"), []byte(user), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conInfo = &Connection{T: "t_order"}
+	order = generate("t_order")
+	if !strings.Contains(order, "func LoadUserForOrders") {
+		t.Errorf("the user package exists, want LoadUserForOrders")
+	}
+	buildModels(t, filepath.Dir(wd), map[string]string{"order": order, "user": user})
+}
@@ -12,15 +12,39 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 )
 
-var db *goqu.Database
 var conInfo *Connection
+var options = &Options{}
 var Package = ""
 
+const (
+	NullPointer = "pointer" // nullable columns are generated as *T
+	NullSQL     = "sql"     // nullable columns are generated as sql.NullString, sql.NullInt64 ...
+
+	DecimalFloat      = "float"      // DECIMAL columns are generated as float32
+	DecimalString     = "string"     // DECIMAL columns are generated as string
+	DecimalShopspring = "shopspring" // DECIMAL columns are generated as github.com/shopspring/decimal.Decimal
+	DecimalLocal      = "local"      // DECIMAL columns are generated as modelutil.Decimal
+)
+
+// Options controls how columns are mapped to go types
+type Options struct {
+	Null              string   // NullPointer or NullSQL, empty keeps plain types for nullable columns
+	TinyIntBool       bool     // tinyint(1) is generated as bool
+	Decimal           string   // DecimalFloat, DecimalString, DecimalShopspring or DecimalLocal, empty is DecimalFloat
+	Time              bool     // DATETIME, TIMESTAMP and DATE are generated as time.Time, the DSN needs parseTime=true
+	JSONType          string   // type of JSON columns, like types.JSON or github.com/x/types.JSON, empty is json.RawMessage
+	ConfigFile        string   // JSON file with per column and per sql type overrides, see Config
+	AuditColumns      []string // overrides Config.AuditColumns
+	SoftDeleteColumns []string // overrides Config.SoftDeleteColumns
+	TemplateDir       string   // directory of .tmpl files overriding or adding to the built-in templates
+}
+
 type Connection struct {
 	A      string `jsonpb:"u"`      //address
 	D      string `jsonpb:"d"`      //database
 	T      string `jsonpb:"t"`      //table
 	E      string `jsonpb:"e"`      //env
+	F      string `jsonpb:"f"`      //schema file
 	Spacer string `jsonpb:"spacer"` //spacer
 }
 
@@ -30,50 +54,114 @@ func Init() {
 		return
 	}
 
-	s, err := sql.Open("mysql", fmt.Sprintf("%s/%s", conInfo.A, conInfo.D))
+	if len(options.ConfigFile) > 0 {
+		if err := LoadConfig(options.ConfigFile); err != nil {
+			fmt.Println("load config err:", err)
+			return
+		}
+	}
+	if options.AuditColumns != nil {
+		config.AuditColumns = options.AuditColumns
+	}
+	if options.SoftDeleteColumns != nil {
+		config.SoftDeleteColumns = options.SoftDeleteColumns
+	}
+
+	if err := LoadTemplates(options.TemplateDir); err != nil {
+		fmt.Println("load templates err:", err)
+		return
+	}
+
+	source, err := openSource()
 	if err != nil {
-		fmt.Println("open mysql err", err)
+		fmt.Println(err)
 		return
 	}
+	Run(source)
+	if err := WriteAccessor(); err != nil {
+		fmt.Println("write db.go err:", err)
+	}
+	//gitInit()
+	fmt.Println("Congratulation! Finish...")
+}
+
+// openSource opens the schema file when -f is given, otherwise connects to mysql
+func openSource() (SchemaSource, error) {
+	if len(conInfo.F) > 0 {
+		s, err := ParseSchemaFile(conInfo.F)
+		if err != nil {
+			return nil, fmt.Errorf("parse schema file err: %v", err)
+		}
+		if len(s.Tables) == 0 {
+			return nil, fmt.Errorf("schema file [%s] has no CREATE TABLE statement", conInfo.F)
+		}
+		return NewSchemaSource(s), nil
+	}
+
+	dsn := fmt.Sprintf("%s/%s", conInfo.A, conInfo.D)
+	if options.Time {
+		dsn = withParseTime(dsn)
+	}
+	s, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open mysql err %v", err)
+	}
 	if err := s.Ping(); err != nil {
-		fmt.Printf("connection Host [%s], happend error:%v", conInfo.A, err)
-		return
+		return nil, fmt.Errorf("connection Host [%s], happend error:%v", conInfo.A, err)
 	}
-	db = goqu.New("mysql", s)
+	return NewMySQLSource(goqu.New("mysql", s)), nil
+}
 
-	dbInfo := &DBInfo{
-		selectDataBaseName: conInfo.D,
-		ableTables:         NewInfo().FetchOriginTables(conInfo.D).ableTables,
+// withParseTime appends parseTime=true to the DSN unless it is set already,
+// otherwise the driver returns DATETIME as []byte and scanning into time.Time fails
+func withParseTime(dsn string) string {
+	if strings.Contains(dsn, "parseTime=") {
+		return dsn
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&parseTime=true"
 	}
+	return dsn + "?parseTime=true"
+}
+
+// Run generates the model and DDL files of conInfo.T, or of every table when it is empty
+func Run(source SchemaSource) {
+	dbInfo := NewInfo(source)
+	dbInfo.selectDataBaseName = conInfo.D
+	dbInfo.FetchOriginTables(conInfo.D)
 
 	var generator = func(tableName string) {
-		tableInfo := NewTableInfo().TableProfit(tableName)
-		if len(tableInfo.Fields) == 0 {
+		g := NewGenerateFrom(dbInfo, tableName)
+		if g == nil {
 			return
 		}
-		g := NewGenerate(dbInfo, tableInfo).Parse()
+		g.Parse()
 		if err := g.Write(); err != nil {
 			fmt.Println("write to file err:", err)
 		}
-		dbInfo.FetchTableDDL(tableName)
 		if err := g.WriteDDL(); err != nil {
 			fmt.Println("writeDDl to file err:", err)
 		}
 	}
 	if len(conInfo.T) == 0 {
 		for _, t := range dbInfo.ableTables {
-			_t := conInfo.D + "." + t
 			dbInfo.selectTableName = t
-			generator(_t)
+			generator(qualifiedName(t))
 		}
 
 	} else {
 		dbInfo.selectTableName = conInfo.T
-		generator(conInfo.D + "." + conInfo.T)
+		generator(qualifiedName(conInfo.T))
 
 	}
-	//gitInit()
-	fmt.Println("Congratulation! Finish...")
+}
+
+// qualifiedName prefixes the table with the database, a schema file may have no database
+func qualifiedName(table string) string {
+	if len(conInfo.D) == 0 {
+		return table
+	}
+	return conInfo.D + "." + table
 }
 
 func gitInit() {
@@ -115,7 +203,13 @@ func getPackage() string {
 
 }
 
-func SaveConfig(a, d, t, e string) bool {
+// SaveOptions sets the type mapping options used by the generator
+func SaveOptions(o *Options) {
+	o.JSONType = registerType(o.JSONType)
+	options = o
+}
+
+func SaveConfig(a, d, t, e, f string) bool {
 	var spacer string
 	switch e {
 	case "windows":
@@ -125,7 +219,7 @@ func SaveConfig(a, d, t, e string) bool {
 	default:
 		spacer = "/"
 	}
-	c := &Connection{A: a, D: d, T: t, E: e, Spacer: spacer}
+	c := &Connection{A: a, D: d, T: t, E: e, F: f, Spacer: spacer}
 	conInfo = c
 	return true
 }
@@ -0,0 +1,668 @@
+package mysql
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MigrationDir is the directory under doc holding the numbered migrations of all tables
+const MigrationDir = "migrations"
+
+// TableDiff is the structured difference between two versions of a table,
+// columns and indexes are matched by name so reordering them is not a change
+type TableDiff struct {
+	Table           string
+	From            *CreateTable
+	To              *CreateTable
+	AddColumns      []*ColumnDef
+	DropColumns     []*ColumnDef
+	ModifyColumns   []*ColumnChange
+	RenameColumns   []*ColumnChange // From and To have different names
+	AddIndexes      []*IndexDef     // a changed index is dropped and added again, the same for the constraints
+	DropIndexes     []*IndexDef
+	AddForeignKeys  []*ForeignKeyDef
+	DropForeignKeys []*ForeignKeyDef
+	AddChecks       []*CheckDef
+	DropChecks      []*CheckDef
+	Options         []*OptionChange
+	Partition       bool     // the PARTITION BY clause changed
+	Warnings        []string // dropped and added columns that may be a rename, dropped options that stay
+}
+
+// ColumnChange is a column whose definition, position or name changed
+type ColumnChange struct {
+	From  *ColumnDef
+	To    *ColumnDef
+	Moved bool // the position changed among the columns both versions have
+}
+
+// OptionChange is a table option that was added, changed or dropped, From is empty when
+// added and To when dropped
+type OptionChange struct {
+	Name string
+	From string
+	To   string
+}
+
+// DiffTables compares two versions of a table, AUTO_INCREMENT counters are not compared.
+// renames maps old column names to new ones, see Config.Renames. Without a hint a dropped
+// column is renamed when exactly one added column has its definition at the same position,
+// the other dropped and added columns sharing a definition or a position are reported in Warnings
+func DiffTables(from, to *CreateTable, renames map[string]string) *TableDiff {
+	d := &TableDiff{Table: to.Name, From: from, To: to}
+
+	var dropped, added []*ColumnDef
+	for _, c := range from.Columns {
+		if to.Column(c.Name) == nil {
+			dropped = append(dropped, c)
+		}
+	}
+	for _, c := range to.Columns {
+		if from.Column(c.Name) == nil {
+			added = append(added, c)
+		}
+	}
+	// newName maps the lower case names of the kept and renamed columns to their name in to
+	newName := make(map[string]string)
+	for _, c := range from.Columns {
+		if n := to.Column(c.Name); n != nil {
+			newName[strings.ToLower(c.Name)] = n.Name
+		}
+	}
+	rename := func(o, n *ColumnDef) {
+		d.RenameColumns = append(d.RenameColumns, &ColumnChange{From: o, To: n})
+		newName[strings.ToLower(o.Name)] = n.Name
+		dropped, added = removeColumn(dropped, o), removeColumn(added, n)
+	}
+	for _, o := range append([]*ColumnDef(nil), dropped...) {
+		for k, v := range renames {
+			if n := to.Column(v); strings.EqualFold(k, o.Name) && n != nil && containsColumn(added, n) {
+				rename(o, n)
+				break
+			}
+		}
+	}
+	for _, o := range append([]*ColumnDef(nil), dropped...) {
+		candidates := sameDefinition(o, added)
+		if len(candidates) == 1 && len(sameDefinition(candidates[0], dropped)) == 1 &&
+			from.position(o) == to.position(candidates[0]) {
+			rename(o, candidates[0])
+			continue
+		}
+		for _, n := range candidates {
+			d.Warnings = append(d.Warnings, fmt.Sprintf("%s: column %s is dropped and %s added with the same definition, "+
+				"add \"%s.%s\": \"%s\" to renames in the config if it is a rename", d.Table, o.Name, n.Name, d.Table, o.Name, n.Name))
+		}
+		// a rename that also changes the definition is only guessed from the position
+		if len(candidates) == 0 {
+			for _, n := range added {
+				if from.position(o) == to.position(n) {
+					d.Warnings = append(d.Warnings, fmt.Sprintf("%s: column %s is dropped and %s added at its position, "+
+						"add \"%s.%s\": \"%s\" to renames in the config if it is a rename", d.Table, o.Name, n.Name, d.Table, o.Name, n.Name))
+				}
+			}
+		}
+	}
+	d.DropColumns, d.AddColumns = dropped, added
+
+	moved := movedColumns(from, to, newName)
+	for _, r := range d.RenameColumns {
+		r.Moved = moved[strings.ToLower(r.To.Name)]
+	}
+	for _, c := range to.Columns {
+		old := from.Column(c.Name)
+		if old == nil {
+			continue
+		}
+		m := moved[strings.ToLower(c.Name)]
+		if m || old.String() != c.String() {
+			d.ModifyColumns = append(d.ModifyColumns, &ColumnChange{From: old, To: c, Moved: m})
+		}
+	}
+
+	// the keys of renamed columns follow them, so they are compared under the new names
+	for _, idx := range from.Indexes {
+		if n := to.index(idx.Name); n == nil || n.String() != idx.renamed(newName).String() {
+			d.DropIndexes = append(d.DropIndexes, idx)
+		}
+	}
+	for _, idx := range to.Indexes {
+		if o := from.index(idx.Name); o == nil || o.renamed(newName).String() != idx.String() {
+			d.AddIndexes = append(d.AddIndexes, idx)
+		}
+	}
+
+	for _, fk := range from.ForeignKeys {
+		if n := to.foreignKey(fk.Name); n == nil || n.String() != fk.renamed(newName).String() {
+			d.DropForeignKeys = append(d.DropForeignKeys, fk)
+		}
+	}
+	for _, fk := range to.ForeignKeys {
+		if o := from.foreignKey(fk.Name); o == nil || o.renamed(newName).String() != fk.String() {
+			d.AddForeignKeys = append(d.AddForeignKeys, fk)
+		}
+	}
+	for _, ck := range from.Checks {
+		if n := to.check(ck.Name); n == nil || n.String() != ck.String() {
+			d.DropChecks = append(d.DropChecks, ck)
+		}
+	}
+	for _, ck := range to.Checks {
+		if o := from.check(ck.Name); o == nil || o.String() != ck.String() {
+			d.AddChecks = append(d.AddChecks, ck)
+		}
+	}
+	d.Partition = from.Partition != to.Partition
+
+	old, current := from.optionMap(), to.optionMap()
+	for _, o := range to.Options {
+		if o.Name == "AUTO_INCREMENT" {
+			continue
+		}
+		if v, ok := old[o.Name]; !ok || !sameOption(o.Name, v, o.Value) {
+			d.Options = append(d.Options, &OptionChange{Name: o.Name, From: v, To: o.Value})
+		}
+	}
+	// a dropped comment is cleared and a dropped collation falls back to the default of the
+	// charset, MySQL keeps the value of the other dropped options
+	for _, o := range from.Options {
+		if _, ok := current[o.Name]; ok || o.Name == "AUTO_INCREMENT" {
+			continue
+		}
+		if o.Name == "COMMENT" {
+			d.Options = append(d.Options, &OptionChange{Name: o.Name, From: o.Value, To: "''"})
+			continue
+		}
+		d.Options = append(d.Options, &OptionChange{Name: o.Name, From: o.Value})
+		if _, ok := current["DEFAULT CHARSET"]; o.Name != "COLLATE" || !ok {
+			d.Warnings = append(d.Warnings, fmt.Sprintf("%s: option %s=%s is dropped, MySQL keeps it until it is set by hand",
+				d.Table, o.Name, o.Value))
+		}
+	}
+	return d
+}
+
+// sameDefinition returns the columns defined like c apart from the name
+func sameDefinition(c *ColumnDef, columns []*ColumnDef) []*ColumnDef {
+	var res []*ColumnDef
+	for _, n := range columns {
+		o := *c
+		o.Name = n.Name
+		if o.String() == n.String() {
+			res = append(res, n)
+		}
+	}
+	return res
+}
+
+func containsColumn(columns []*ColumnDef, c *ColumnDef) bool {
+	for _, col := range columns {
+		if col == c {
+			return true
+		}
+	}
+	return false
+}
+
+func removeColumn(columns []*ColumnDef, c *ColumnDef) []*ColumnDef {
+	res := make([]*ColumnDef, 0, len(columns))
+	for _, col := range columns {
+		if col != c {
+			res = append(res, col)
+		}
+	}
+	return res
+}
+
+// position returns the index of the column in the table
+func (c *CreateTable) position(col *ColumnDef) int {
+	for i, cc := range c.Columns {
+		if cc == col {
+			return i
+		}
+	}
+	return -1
+}
+
+// renamed returns a copy of the key with the key parts renamed by newName
+func (i *IndexDef) renamed(newName map[string]string) *IndexDef {
+	n := *i
+	n.Columns = make([]*IndexColumn, 0, len(i.Columns))
+	for _, c := range i.Columns {
+		ic := *c
+		if name, ok := newName[strings.ToLower(c.Name)]; ok {
+			ic.Name = name
+		}
+		n.Columns = append(n.Columns, &ic)
+	}
+	return &n
+}
+
+// movedColumns finds the kept and renamed columns out of order, the longest common ordering stays in place.
+// newName maps their lower case names in from to their names in to
+func movedColumns(from, to *CreateTable, newName map[string]string) map[string]bool {
+	var a, b []string
+	for _, c := range from.Columns {
+		if n, ok := newName[strings.ToLower(c.Name)]; ok {
+			a = append(a, strings.ToLower(n))
+		}
+	}
+	kept := make(map[string]bool)
+	for _, n := range a {
+		kept[n] = true
+	}
+	for _, c := range to.Columns {
+		if kept[strings.ToLower(c.Name)] {
+			b = append(b, strings.ToLower(c.Name))
+		}
+	}
+	// lcs[i][j] is the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	moved := make(map[string]bool)
+	for _, c := range b {
+		moved[c] = true
+	}
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			delete(moved, b[j])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return moved
+}
+
+// IsEmpty reports whether both versions are the same
+func (d *TableDiff) IsEmpty() bool {
+	return len(d.AddColumns)+len(d.DropColumns)+len(d.ModifyColumns)+len(d.RenameColumns)+
+		len(d.AddIndexes)+len(d.DropIndexes)+len(d.AddForeignKeys)+len(d.DropForeignKeys)+
+		len(d.AddChecks)+len(d.DropChecks)+len(d.Options) == 0 && !d.Partition
+}
+
+// Up returns the ALTER TABLE statements turning From into To. Indexes are dropped first,
+// then columns, the columns are added and modified in the order of To so every AFTER
+// names a column already in place, and the indexes are added last
+func (d *TableDiff) Up() []string {
+	table := quoteIdent(d.Table)
+	var stmts []string
+	alter := func(format string, args ...interface{}) {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s %s;", table, fmt.Sprintf(format, args...)))
+	}
+	// a foreign key needs its index, and a check its columns
+	for _, fk := range d.DropForeignKeys {
+		alter("DROP FOREIGN KEY %s", quoteIdent(fk.Name))
+	}
+	for _, ck := range d.DropChecks {
+		alter("DROP CHECK %s", quoteIdent(ck.Name))
+	}
+	for _, idx := range d.DropIndexes {
+		if idx.Kind == "PRIMARY" {
+			alter("DROP PRIMARY KEY")
+		} else {
+			alter("DROP INDEX %s", quoteIdent(idx.Name))
+		}
+	}
+	for _, c := range d.DropColumns {
+		alter("DROP COLUMN %s", quoteIdent(c.Name))
+	}
+	for i, c := range d.To.Columns {
+		position := "FIRST"
+		if i > 0 {
+			position = "AFTER " + quoteIdent(d.To.Columns[i-1].Name)
+		}
+		if containsColumn(d.AddColumns, c) {
+			alter("ADD COLUMN %s %s", c, position)
+		} else if r := d.renamed(c); r != nil {
+			// CHANGE COLUMN works on MySQL 5.7, RENAME COLUMN needs 8.0
+			if r.Moved {
+				alter("CHANGE COLUMN %s %s %s", quoteIdent(r.From.Name), c, position)
+			} else {
+				alter("CHANGE COLUMN %s %s", quoteIdent(r.From.Name), c)
+			}
+		} else if ch := d.modified(c); ch != nil {
+			if ch.Moved {
+				alter("MODIFY COLUMN %s %s", c, position)
+			} else {
+				alter("MODIFY COLUMN %s", c)
+			}
+		}
+	}
+	for _, idx := range d.AddIndexes {
+		alter("ADD %s", idx)
+	}
+	for _, fk := range d.AddForeignKeys {
+		alter("ADD %s", fk)
+	}
+	for _, ck := range d.AddChecks {
+		alter("ADD %s", ck)
+	}
+	for _, o := range d.Options {
+		switch {
+		case len(o.To) > 0:
+			alter("%s=%s", o.Name, o.To)
+		case o.Name == "COLLATE" && d.option("DEFAULT CHARSET") == nil:
+			// setting the charset again restores its default collation
+			if charset, ok := d.To.optionMap()["DEFAULT CHARSET"]; ok {
+				alter("DEFAULT CHARSET=%s", charset)
+			}
+		}
+	}
+	if d.Partition && len(d.To.Partition) == 0 {
+		alter("REMOVE PARTITIONING")
+	} else if d.Partition {
+		alter("%s", d.To.Partition)
+	}
+	return stmts
+}
+
+// Down returns the statements turning To back into From, the renames are reverted
+func (d *TableDiff) Down() []string {
+	renames := make(map[string]string)
+	for _, r := range d.RenameColumns {
+		renames[r.To.Name] = r.From.Name
+	}
+	return DiffTables(d.To, d.From, renames).Up()
+}
+
+// Description names the change for a migration file, like add_column_email, alter when it has several
+func (d *TableDiff) Description() string {
+	var names []string
+	for _, c := range d.AddColumns {
+		names = append(names, "add_column_"+c.Name)
+	}
+	for _, c := range d.DropColumns {
+		names = append(names, "drop_column_"+c.Name)
+	}
+	for _, c := range d.ModifyColumns {
+		names = append(names, "modify_column_"+c.To.Name)
+	}
+	for _, c := range d.RenameColumns {
+		names = append(names, "rename_column_"+c.From.Name)
+	}
+	added := make(map[string]bool)
+	for _, idx := range d.AddIndexes {
+		added[idx.Name] = true
+	}
+	for _, idx := range d.DropIndexes {
+		if added[idx.Name] {
+			names = append(names, "modify_index_"+idx.Name)
+			delete(added, idx.Name)
+		} else {
+			names = append(names, "drop_index_"+idx.Name)
+		}
+	}
+	for _, idx := range d.AddIndexes {
+		if added[idx.Name] {
+			names = append(names, "add_index_"+idx.Name)
+		}
+	}
+	for _, fk := range d.DropForeignKeys {
+		names = append(names, "drop_foreign_key_"+fk.Name)
+	}
+	for _, fk := range d.AddForeignKeys {
+		names = append(names, "add_foreign_key_"+fk.Name)
+	}
+	for _, ck := range d.DropChecks {
+		names = append(names, "drop_check_"+ck.Name)
+	}
+	for _, ck := range d.AddChecks {
+		names = append(names, "add_check_"+ck.Name)
+	}
+	if len(d.Options) > 0 {
+		names = append(names, "options")
+	}
+	if d.Partition {
+		names = append(names, "partition")
+	}
+	if len(names) != 1 {
+		return "alter"
+	}
+	return migrationName(names[0])
+}
+
+func (d *TableDiff) option(name string) *OptionChange {
+	for _, o := range d.Options {
+		if o.Name == name {
+			return o
+		}
+	}
+	return nil
+}
+
+func (d *TableDiff) renamed(c *ColumnDef) *ColumnChange {
+	for _, r := range d.RenameColumns {
+		if r.To == c {
+			return r
+		}
+	}
+	return nil
+}
+
+func (d *TableDiff) modified(c *ColumnDef) *ColumnChange {
+	for _, m := range d.ModifyColumns {
+		if m.To == c {
+			return m
+		}
+	}
+	return nil
+}
+
+// index returns the key by name
+func (c *CreateTable) index(name string) *IndexDef {
+	for _, idx := range c.Indexes {
+		if strings.EqualFold(idx.Name, name) {
+			return idx
+		}
+	}
+	return nil
+}
+
+// foreignKey returns the foreign key by name
+func (c *CreateTable) foreignKey(name string) *ForeignKeyDef {
+	for _, fk := range c.ForeignKeys {
+		if strings.EqualFold(fk.Name, name) {
+			return fk
+		}
+	}
+	return nil
+}
+
+// check returns the check by name
+func (c *CreateTable) check(name string) *CheckDef {
+	for _, ck := range c.Checks {
+		if strings.EqualFold(ck.Name, name) {
+			return ck
+		}
+	}
+	return nil
+}
+
+// renamed returns a copy of the foreign key with its columns renamed by newName
+func (f *ForeignKeyDef) renamed(newName map[string]string) *ForeignKeyDef {
+	n := *f
+	n.Columns = make([]string, 0, len(f.Columns))
+	for _, c := range f.Columns {
+		if name, ok := newName[strings.ToLower(c)]; ok {
+			c = name
+		}
+		n.Columns = append(n.Columns, c)
+	}
+	return &n
+}
+
+// sameOption compares option values, the engine, charset and collation names ignore case
+func sameOption(name, a, b string) bool {
+	switch name {
+	case "ENGINE", "DEFAULT CHARSET", "COLLATE":
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+func (c *CreateTable) optionMap() map[string]string {
+	m := make(map[string]string)
+	for _, o := range c.Options {
+		m[o.Name] = o.Value
+	}
+	return m
+}
+
+// withoutAutoIncrement returns a copy of c without the AUTO_INCREMENT counter, which
+// changes with the data and does not belong in a snapshot
+func (c *CreateTable) withoutAutoIncrement() *CreateTable {
+	n := *c
+	n.Options = nil
+	for _, o := range c.Options {
+		if o.Name != "AUTO_INCREMENT" {
+			n.Options = append(n.Options, o)
+		}
+	}
+	return &n
+}
+
+var migrationNameRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+func migrationName(s string) string {
+	return strings.Trim(migrationNameRe.ReplaceAllString(strings.ToLower(s), "_"), "_")
+}
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_.*\.(up|down)\.sql$`)
+
+// nextMigrationVersion returns the version after the highest one in dir, starting at 1
+func nextMigrationVersion(dir string) (int, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	version := 0
+	for _, f := range files {
+		m := migrationFileRe.FindStringSubmatch(f.Name())
+		if m == nil {
+			continue
+		}
+		if n, err := strconv.Atoi(m[1]); err == nil && n > version {
+			version = n
+		}
+	}
+	return version + 1, nil
+}
+
+// WriteMigration writes NNNN_<table>_<desc>.up.sql and .down.sql into dir with the next version,
+// the naming of golang-migrate, and returns the path of the up file
+func WriteMigration(dir, table, desc string, up, down []string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	version, err := nextMigrationVersion(dir)
+	if err != nil {
+		return "", err
+	}
+	base := filepath.Join(dir, fmt.Sprintf("%04d_%s_%s", version, migrationName(table), desc))
+	if err := ioutil.WriteFile(base+".up.sql", []byte(strings.Join(up, "\n")+"\n"), 0644); err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(base+".down.sql", []byte(strings.Join(down, "\n")+"\n"), 0644); err != nil {
+		return "", err
+	}
+	return base + ".up.sql", nil
+}
+
+// readSnapshot parses the last CREATE TABLE of a snapshot file, the history files written
+// by earlier versions hold one per change and are read the same way
+func readSnapshot(file string) (*CreateTable, error) {
+	s, err := ParseSchemaFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Tables) == 0 {
+		return nil, fmt.Errorf("snapshot [%s] has no CREATE TABLE statement", file)
+	}
+	return s.Tables[len(s.Tables)-1], nil
+}
+
+// WriteDDL keeps doc/<table>/<table>.sql as the snapshot of the table and writes a migration
+// into doc/migrations when the table differs from it, the first run writes the CREATE TABLE
+func (g *Generate) WriteDDL() error {
+	_dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if arr := strings.Split(_dir, "/"); arr[len(arr)-1] != "model" {
+		return fmt.Errorf("Current path [%s], Must come into the model dir execute ", _dir)
+	}
+
+	s, err := ParseSchema(g.dbInfo.selectTableDDL)
+	if err != nil {
+		return err
+	}
+	if len(s.Tables) == 0 {
+		return fmt.Errorf("table [%s] has no CREATE TABLE statement", g.dbInfo.selectTableName)
+	}
+	current := s.Tables[0].withoutAutoIncrement()
+
+	docDir := filepath.Join(filepath.Dir(_dir), "doc")
+	name := strings.ToLower(g.structName[0:1]) + g.structName[1:]
+	file := filepath.Join(docDir, name, name+".sql")
+
+	var up, down []string
+	var desc string
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		up = []string{current.DDL() + ";"}
+		down = []string{fmt.Sprintf("DROP TABLE %s;", quoteIdent(current.Name))}
+		desc = "create"
+	} else {
+		previous, err := readSnapshot(file)
+		if err != nil {
+			return err
+		}
+		d := DiffTables(previous, current, config.renameHints(current.Name))
+		if d.IsEmpty() {
+			return nil
+		}
+		up, down, desc = d.Up(), d.Down(), d.Description()
+		// the warnings lead the up file, so they are seen before it is applied
+		var warnings []string
+		for _, w := range d.Warnings {
+			fmt.Println("WARNING:", w)
+			warnings = append(warnings, "-- WARNING: "+w)
+		}
+		up = append(warnings, up...)
+	}
+
+	migration, err := WriteMigration(filepath.Join(docDir, MigrationDir), current.Name, desc, up, down)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(file, []byte(current.DDL()+";\n"), 0644); err != nil {
+		return err
+	}
+	fmt.Println("Create [" + migration + "] Success")
+	return nil
+}
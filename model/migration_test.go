@@ -0,0 +1,155 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+const diffBase = "CREATE TABLE t (id int NOT NULL, a varchar(10) DEFAULT NULL, b varchar(10) DEFAULT NULL, c int NOT NULL, PRIMARY KEY (id), KEY idx_a (a))"
+
+func TestDiffTablesRenames(t *testing.T) {
+	cases := []struct {
+		name     string
+		to       string
+		renames  map[string]string
+		up       []string
+		down     []string
+		warnings int
+	}{
+		{
+			name: "plain rename",
+			to:   "CREATE TABLE t (id int NOT NULL, name varchar(10) DEFAULT NULL, b varchar(10) DEFAULT NULL, c int NOT NULL, PRIMARY KEY (id), KEY idx_a (name))",
+			up:   []string{"ALTER TABLE `t` CHANGE COLUMN `a` `name` varchar(10) DEFAULT NULL;"},
+			down: []string{"ALTER TABLE `t` CHANGE COLUMN `name` `a` varchar(10) DEFAULT NULL;"},
+		},
+		{
+			name: "ambiguous rename",
+			to:   "CREATE TABLE t (id int NOT NULL, x varchar(10) DEFAULT NULL, y varchar(10) DEFAULT NULL, c int NOT NULL, PRIMARY KEY (id), KEY idx_a (x))",
+			up: []string{
+				"ALTER TABLE `t` DROP INDEX `idx_a`;",
+				"ALTER TABLE `t` DROP COLUMN `a`;",
+				"ALTER TABLE `t` DROP COLUMN `b`;",
+				"ALTER TABLE `t` ADD COLUMN `x` varchar(10) DEFAULT NULL AFTER `id`;",
+				"ALTER TABLE `t` ADD COLUMN `y` varchar(10) DEFAULT NULL AFTER `x`;",
+				"ALTER TABLE `t` ADD KEY `idx_a` (`x`);",
+			},
+			warnings: 4,
+		},
+		{
+			name:    "ambiguous rename with hints",
+			to:      "CREATE TABLE t (id int NOT NULL, x varchar(10) DEFAULT NULL, y varchar(10) DEFAULT NULL, c int NOT NULL, PRIMARY KEY (id), KEY idx_a (x))",
+			renames: map[string]string{"a": "y", "b": "x"},
+			up: []string{
+				"ALTER TABLE `t` DROP INDEX `idx_a`;",
+				"ALTER TABLE `t` CHANGE COLUMN `b` `x` varchar(10) DEFAULT NULL;",
+				"ALTER TABLE `t` CHANGE COLUMN `a` `y` varchar(10) DEFAULT NULL AFTER `x`;",
+				"ALTER TABLE `t` ADD KEY `idx_a` (`x`);",
+			},
+		},
+		{
+			name: "reorder",
+			to:   "CREATE TABLE t (id int NOT NULL, c int NOT NULL, a varchar(10) DEFAULT NULL, b varchar(10) DEFAULT NULL, PRIMARY KEY (id), KEY idx_a (a))",
+			up:   []string{"ALTER TABLE `t` MODIFY COLUMN `c` int NOT NULL AFTER `id`;"},
+			down: []string{"ALTER TABLE `t` MODIFY COLUMN `c` int NOT NULL AFTER `b`;"},
+		},
+		{
+			name: "type change and rename",
+			to:   "CREATE TABLE t (id int NOT NULL, title varchar(64) NOT NULL, b varchar(10) DEFAULT NULL, c int NOT NULL, PRIMARY KEY (id), KEY idx_a (title))",
+			up: []string{
+				"ALTER TABLE `t` DROP INDEX `idx_a`;",
+				"ALTER TABLE `t` DROP COLUMN `a`;",
+				"ALTER TABLE `t` ADD COLUMN `title` varchar(64) NOT NULL AFTER `id`;",
+				"ALTER TABLE `t` ADD KEY `idx_a` (`title`);",
+			},
+			warnings: 1,
+		},
+		{
+			name:    "type change and rename with a hint",
+			to:      "CREATE TABLE t (id int NOT NULL, title varchar(64) NOT NULL, b varchar(10) DEFAULT NULL, c int NOT NULL, PRIMARY KEY (id), KEY idx_a (title))",
+			renames: map[string]string{"a": "title"},
+			up:      []string{"ALTER TABLE `t` CHANGE COLUMN `a` `title` varchar(64) NOT NULL;"},
+			down:    []string{"ALTER TABLE `t` CHANGE COLUMN `title` `a` varchar(10) DEFAULT NULL;"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			from, err := ParseSchema(diffBase)
+			if err != nil {
+				t.Fatal(err)
+			}
+			to, err := ParseSchema(c.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := DiffTables(from.Tables[0], to.Tables[0], c.renames)
+			if got := d.Up(); !reflect.DeepEqual(got, c.up) {
+				t.Errorf("up = %q, want %q", got, c.up)
+			}
+			if got := d.Down(); c.down != nil && !reflect.DeepEqual(got, c.down) {
+				t.Errorf("down = %q, want %q", got, c.down)
+			}
+			if len(d.Warnings) != c.warnings {
+				t.Errorf("warnings = %q, want %d", d.Warnings, c.warnings)
+			}
+		})
+	}
+}
+
+func TestDiffTablesOptions(t *testing.T) {
+	const from = "CREATE TABLE t (id int NOT NULL) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin COMMENT='users'"
+	cases := []struct {
+		name     string
+		to       string
+		up       []string
+		warnings int
+	}{
+		{
+			name: "case of the names",
+			to:   "CREATE TABLE t (id int NOT NULL) ENGINE=innodb DEFAULT CHARSET=UTF8MB4 COLLATE=UTF8MB4_BIN COMMENT='users'",
+		},
+		{
+			name: "comment dropped",
+			to:   "CREATE TABLE t (id int NOT NULL) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin",
+			up:   []string{"ALTER TABLE `t` COMMENT='';"},
+		},
+		{
+			name: "collate dropped",
+			to:   "CREATE TABLE t (id int NOT NULL) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='users'",
+			up:   []string{"ALTER TABLE `t` DEFAULT CHARSET=utf8mb4;"},
+		},
+		{
+			name:     "charset and collate dropped",
+			to:       "CREATE TABLE t (id int NOT NULL) ENGINE=InnoDB COMMENT='users'",
+			warnings: 2,
+		},
+		{
+			name: "collate changed",
+			to:   "CREATE TABLE t (id int NOT NULL) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='users'",
+			up:   []string{"ALTER TABLE `t` COLLATE=utf8mb4_general_ci;"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f, err := ParseSchema(from)
+			if err != nil {
+				t.Fatal(err)
+			}
+			to, err := ParseSchema(c.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := DiffTables(f.Tables[0], to.Tables[0], nil)
+			if got := d.Up(); !reflect.DeepEqual(got, c.up) {
+				t.Errorf("up = %q, want %q", got, c.up)
+			}
+			if len(d.Warnings) != c.warnings {
+				t.Errorf("warnings = %q, want %d", d.Warnings, c.warnings)
+			}
+			// the dropped options are reported from both sides
+			back := DiffTables(to.Tables[0], f.Tables[0], nil)
+			if d.IsEmpty() != back.IsEmpty() {
+				t.Errorf("empty = %v, reversed %v", d.IsEmpty(), back.IsEmpty())
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package mysql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// SchemaSource provides the table structures the generator works from
+type SchemaSource interface {
+	// Tables lists the table names of the database
+	Tables(database string) ([]string, error)
+	// Columns describes the columns of the table, the same as `show full columns`
+	Columns(table string) ([]*FieldInfo, error)
+	// Indexes describes the key parts of the table, ordered by key name and position
+	Indexes(table string) ([]*IndexInfo, error)
+	// ForeignKeys describes the columns of the foreign keys of the table, ordered by
+	// constraint name and position
+	ForeignKeys(table string) ([]*ForeignKeyInfo, error)
+	// TableDDL returns the `SHOW CREATE TABLE` statement of the table
+	TableDDL(table string) (string, error)
+}
+
+// MySQLSource reads the structures from a live MySQL server
+type MySQLSource struct {
+	db *goqu.Database
+}
+
+func NewMySQLSource(db *goqu.Database) *MySQLSource {
+	return &MySQLSource{db: db}
+}
+
+func (m *MySQLSource) Tables(database string) ([]string, error) {
+	sql := "SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA=?"
+	return m.scanColumn(sql, database)
+}
+
+func (m *MySQLSource) scanColumn(sql string, args ...interface{}) ([]string, error) {
+	res, err := m.db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	strs := make([]string, 0, 10)
+	for res.Next() {
+		var s string
+		if err := res.Scan(&s); err != nil {
+			return strs, err
+		}
+		strs = append(strs, s)
+	}
+	return strs, res.Err()
+}
+
+func (m *MySQLSource) Columns(table string) ([]*FieldInfo, error) {
+	sql := "show full columns from " + table
+	var res []*FieldInfo
+	if err := m.db.ScanStructs(&res, sql); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (m *MySQLSource) Indexes(table string) ([]*IndexInfo, error) {
+	schema := "DATABASE()"
+	args := make([]interface{}, 0, 2)
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		schema = "?"
+		args = append(args, table[:i])
+		table = table[i+1:]
+	}
+	args = append(args, table)
+	sql := fmt.Sprintf("SELECT INDEX_NAME, COALESCE(COLUMN_NAME, '') AS COLUMN_NAME, NON_UNIQUE, SEQ_IN_INDEX, SUB_PART, INDEX_TYPE "+
+		"FROM information_schema.STATISTICS WHERE TABLE_SCHEMA=%s AND TABLE_NAME=? ORDER BY INDEX_NAME, SEQ_IN_INDEX", schema)
+	var res []*IndexInfo
+	if err := m.db.ScanStructs(&res, sql, args...); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (m *MySQLSource) ForeignKeys(table string) ([]*ForeignKeyInfo, error) {
+	schema := "DATABASE()"
+	args := make([]interface{}, 0, 2)
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		schema = "?"
+		args = append(args, table[:i])
+		table = table[i+1:]
+	}
+	args = append(args, table)
+	sql := fmt.Sprintf("SELECT k.CONSTRAINT_NAME, k.COLUMN_NAME, k.ORDINAL_POSITION, "+
+		"IF(k.REFERENCED_TABLE_SCHEMA = k.TABLE_SCHEMA, '', k.REFERENCED_TABLE_SCHEMA) AS REFERENCED_TABLE_SCHEMA, "+
+		"k.REFERENCED_TABLE_NAME, k.REFERENCED_COLUMN_NAME, r.UPDATE_RULE, r.DELETE_RULE "+
+		"FROM information_schema.KEY_COLUMN_USAGE k JOIN information_schema.REFERENTIAL_CONSTRAINTS r "+
+		"ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA AND r.TABLE_NAME = k.TABLE_NAME AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME "+
+		"WHERE k.TABLE_SCHEMA=%s AND k.TABLE_NAME=? AND k.REFERENCED_TABLE_NAME IS NOT NULL "+
+		"ORDER BY k.CONSTRAINT_NAME, k.ORDINAL_POSITION", schema)
+	var res []*ForeignKeyInfo
+	if err := m.db.ScanStructs(&res, sql, args...); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (m *MySQLSource) TableDDL(table string) (string, error) {
+	sql := fmt.Sprintf("SHOW CREATE TABLE %s", table)
+	var res []*DDLInfo
+	if err := m.db.ScanStructs(&res, sql); err != nil {
+		return "", err
+	}
+	if len(res) == 0 {
+		return "", fmt.Errorf("table %s not found", table)
+	}
+	return res[0].CreateTable, nil
+}
+
+// MemorySource keeps the structures in memory, it backs the schema file mode and
+// lets the generator run on fixtures
+type MemorySource struct {
+	names  []string
+	tables map[string]*memoryTable
+}
+
+type memoryTable struct {
+	fields      []*FieldInfo
+	indexes     []*IndexInfo
+	foreignKeys []*ForeignKeyInfo
+	ddl         string
+}
+
+func NewMemorySource() *MemorySource {
+	return &MemorySource{tables: make(map[string]*memoryTable)}
+}
+
+// NewSchemaSource builds a MemorySource from a parsed schema file
+func NewSchemaSource(s *Schema) *MemorySource {
+	m := NewMemorySource()
+	for _, t := range s.Tables {
+		m.AddTable(t.Name, t.Fields(), t.IndexInfos(), t.DDL(), t.ForeignKeyInfos()...)
+	}
+	return m
+}
+
+// AddTable adds or replaces a table, Tables returns the tables in the order they are added
+func (m *MemorySource) AddTable(name string, fields []*FieldInfo, indexes []*IndexInfo, ddl string, foreignKeys ...*ForeignKeyInfo) *MemorySource {
+	key := strings.ToLower(name)
+	if _, ok := m.tables[key]; !ok {
+		m.names = append(m.names, name)
+	}
+	m.tables[key] = &memoryTable{fields: fields, indexes: indexes, foreignKeys: foreignKeys, ddl: ddl}
+	return m
+}
+
+func (m *MemorySource) table(name string) (*memoryTable, error) {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	t, ok := m.tables[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("table %s not found", name)
+	}
+	return t, nil
+}
+
+func (m *MemorySource) Tables(database string) ([]string, error) {
+	return append([]string(nil), m.names...), nil
+}
+
+func (m *MemorySource) Columns(table string) ([]*FieldInfo, error) {
+	t, err := m.table(table)
+	if err != nil {
+		return nil, err
+	}
+	return t.fields, nil
+}
+
+func (m *MemorySource) Indexes(table string) ([]*IndexInfo, error) {
+	t, err := m.table(table)
+	if err != nil {
+		return nil, err
+	}
+	return t.indexes, nil
+}
+
+func (m *MemorySource) ForeignKeys(table string) ([]*ForeignKeyInfo, error) {
+	t, err := m.table(table)
+	if err != nil {
+		return nil, err
+	}
+	return t.foreignKeys, nil
+}
+
+func (m *MemorySource) TableDDL(table string) (string, error) {
+	t, err := m.table(table)
+	if err != nil {
+		return "", err
+	}
+	return t.ddl, nil
+}
@@ -2,12 +2,18 @@ package mysql
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+
+	"github.com/golang/protobuf/protoc-gen-go/generator"
 )
 
 type TableInfo struct {
-	Fields    []*FieldInfo
-	TableName string
+	Fields      []*FieldInfo
+	Indexes     []*Index
+	ForeignKeys []*ForeignKey
+	TableName   string
+	source      SchemaSource
 }
 
 type FieldInfo struct {
@@ -22,83 +28,510 @@ type FieldInfo struct {
 	Privileges string  `db:"Privileges"`
 }
 
+// IndexInfo is one key part of a table index
+type IndexInfo struct {
+	KeyName    string `db:"INDEX_NAME"`
+	ColumnName string `db:"COLUMN_NAME"`
+	NonUnique  int    `db:"NON_UNIQUE"`
+	SeqInIndex int    `db:"SEQ_IN_INDEX"`
+	SubPart    *int64 `db:"SUB_PART"`
+	IndexType  string `db:"INDEX_TYPE"`
+}
+
+// Index is a table index with its columns in key order
+type Index struct {
+	Name    string
+	Primary bool
+	Unique  bool
+	Type    string // BTREE, HASH, FULLTEXT or SPATIAL
+	Columns []string
+}
+
+// ForeignKeyInfo is one column of a foreign key, like information_schema.KEY_COLUMN_USAGE
+// joined with REFERENTIAL_CONSTRAINTS
+type ForeignKeyInfo struct {
+	ConstraintName  string `db:"CONSTRAINT_NAME"`
+	ColumnName      string `db:"COLUMN_NAME"`
+	OrdinalPosition int    `db:"ORDINAL_POSITION"`
+	RefSchema       string `db:"REFERENCED_TABLE_SCHEMA"` // empty when it is the schema of the table
+	RefTable        string `db:"REFERENCED_TABLE_NAME"`
+	RefColumn       string `db:"REFERENCED_COLUMN_NAME"`
+	UpdateRule      string `db:"UPDATE_RULE"`
+	DeleteRule      string `db:"DELETE_RULE"`
+}
+
+// ForeignKey is a foreign key with its columns in key order
+type ForeignKey struct {
+	Name       string
+	Columns    []string
+	RefSchema  string // empty when it is the schema of the table
+	RefTable   string
+	RefColumns []string
+	OnUpdate   string // RESTRICT, CASCADE, SET NULL, NO ACTION or SET DEFAULT
+	OnDelete   string
+}
+
 type DDLInfo struct {
 	Table       string `db:"Table"`
 	CreateTable string `db:"Create Table"`
 }
 
-func NewTableInfo() *TableInfo {
-	return &TableInfo{}
+func NewTableInfo(source SchemaSource) *TableInfo {
+	return &TableInfo{source: source}
 }
 
 func (t *TableInfo) TableProfit(tableName string) *TableInfo {
 	t.TableName = tableName
-	sql := "show full columns from " + tableName
-	var res []*FieldInfo
-	if err := db.ScanStructs(&res, sql); err != nil {
+	res, err := t.source.Columns(tableName)
+	if err != nil {
 		fmt.Println("get table info err:", err)
 		return t
 	}
 	t.Fields = res
+
+	indexes, err := t.source.Indexes(tableName)
+	if err != nil {
+		fmt.Println("get table index err:", err)
+		return t
+	}
+	t.Indexes = groupIndexes(indexes)
+
+	foreignKeys, err := t.source.ForeignKeys(tableName)
+	if err != nil {
+		fmt.Println("get table foreign key err:", err)
+		return t
+	}
+	t.ForeignKeys = groupForeignKeys(foreignKeys)
 	return t
 }
 
+// groupForeignKeys groups the columns by constraint, ordered by name
+func groupForeignKeys(infos []*ForeignKeyInfo) []*ForeignKey {
+	sorted := append([]*ForeignKeyInfo(nil), infos...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].ConstraintName != sorted[j].ConstraintName {
+			return sorted[i].ConstraintName < sorted[j].ConstraintName
+		}
+		return sorted[i].OrdinalPosition < sorted[j].OrdinalPosition
+	})
+	var keys []*ForeignKey
+	byName := make(map[string]*ForeignKey)
+	for _, info := range sorted {
+		fk, ok := byName[info.ConstraintName]
+		if !ok {
+			fk = &ForeignKey{
+				Name:      info.ConstraintName,
+				RefSchema: info.RefSchema,
+				RefTable:  info.RefTable,
+				OnUpdate:  info.UpdateRule,
+				OnDelete:  info.DeleteRule,
+			}
+			byName[info.ConstraintName] = fk
+			keys = append(keys, fk)
+		}
+		fk.Columns = append(fk.Columns, info.ColumnName)
+		fk.RefColumns = append(fk.RefColumns, info.RefColumn)
+	}
+	return keys
+}
+
+// groupIndexes groups the key parts by index, the primary key comes first, then unique
+// keys, then the others, each ordered by name
+func groupIndexes(infos []*IndexInfo) []*Index {
+	sorted := append([]*IndexInfo(nil), infos...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].SeqInIndex < sorted[j].SeqInIndex
+	})
+	var indexes []*Index
+	byName := make(map[string]*Index)
+	for _, info := range sorted {
+		idx, ok := byName[info.KeyName]
+		if !ok {
+			idx = &Index{
+				Name:    info.KeyName,
+				Primary: info.KeyName == "PRIMARY",
+				Unique:  info.NonUnique == 0,
+				Type:    info.IndexType,
+			}
+			byName[info.KeyName] = idx
+			indexes = append(indexes, idx)
+		}
+		if len(info.ColumnName) > 0 {
+			idx.Columns = append(idx.Columns, info.ColumnName)
+		}
+	}
+	rank := func(idx *Index) int {
+		switch {
+		case idx.Primary:
+			return 0
+		case idx.Unique:
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		if rank(indexes[i]) != rank(indexes[j]) {
+			return rank(indexes[i]) < rank(indexes[j])
+		}
+		return indexes[i].Name < indexes[j].Name
+	})
+	return indexes
+}
+
+// Field returns the column by name
+func (t *TableInfo) Field(name string) *FieldInfo {
+	for _, f := range t.Fields {
+		if strings.EqualFold(f.Field, name) {
+			return f
+		}
+	}
+	return nil
+}
+
+// IsNullable reports whether the column accepts NULL
+func (f *FieldInfo) IsNullable() bool {
+	return f.Null == "YES"
+}
+
+// EnumValues returns the allowed values of an ENUM column, nil for other columns
+func (f *FieldInfo) EnumValues() []string {
+	ct := parseColumnType(f.Type)
+	if ct.Name != "enum" {
+		return nil
+	}
+	values := make([]string, 0, len(ct.Args))
+	for _, a := range ct.Args {
+		a = strings.TrimSuffix(strings.TrimPrefix(a, "'"), "'")
+		values = append(values, strings.ReplaceAll(a, "''", "'"))
+	}
+	return values
+}
+
+// Name is the table name without the database qualifier
+func (t *TableInfo) Name() string {
+	if i := strings.LastIndex(t.TableName, "."); i >= 0 {
+		return t.TableName[i+1:]
+	}
+	return t.TableName
+}
+
+// StructName is the go struct name of the table, the t_ prefix is dropped
+func (t *TableInfo) StructName() string {
+	return generator.CamelCase(baseName(t.Name()))
+}
+
+// structSuffixes are appended to the struct name by the built-in templates, like UserRepo
+var structSuffixes = []string{"Columns", "Repo"}
+
+// EnumType is the named string type generated for an ENUM column, like UserStatus. A name the
+// templates use already, like UserRepo for a column named repo, gets an Enum suffix
+func (t *TableInfo) EnumType(f *FieldInfo) string {
+	name := t.StructName() + generator.CamelCase(f.Field)
+	for _, s := range structSuffixes {
+		if name == t.StructName()+s {
+			return name + "Enum"
+		}
+	}
+	return name
+}
+
+// defaultAuditColumns are filled by the database or the caller's hooks, they are
+// skipped on insert and update unless Config.AuditColumns is set
+var defaultAuditColumns = []string{"create_time", "update_time"}
+
+// IsAutoIncrement reports whether the database generates the column value
+func (f *FieldInfo) IsAutoIncrement() bool {
+	return f.Extra != nil && strings.Contains(strings.ToLower(*f.Extra), "auto_increment")
+}
+
+// isGenerated reports whether the column is a VIRTUAL or STORED generated column
+func (f *FieldInfo) isGenerated() bool {
+	if f.Extra == nil {
+		return false
+	}
+	extra := strings.ToLower(*f.Extra)
+	return strings.Contains(extra, "virtual generated") || strings.Contains(extra, "stored generated")
+}
+
+// isOnUpdateTimestamp reports whether the column has ON UPDATE CURRENT_TIMESTAMP
+func (f *FieldInfo) isOnUpdateTimestamp() bool {
+	return f.Extra != nil && strings.Contains(strings.ToLower(*f.Extra), "on update current_timestamp")
+}
+
+// isDefaultTimestamp reports whether the column has DEFAULT CURRENT_TIMESTAMP
+func (f *FieldInfo) isDefaultTimestamp() bool {
+	return f.Default != nil && strings.HasPrefix(strings.ToUpper(*f.Default), "CURRENT_TIMESTAMP")
+}
+
+func isAuditColumn(name string) bool {
+	columns := defaultAuditColumns
+	if config.AuditColumns != nil {
+		columns = config.AuditColumns
+	}
+	for _, c := range columns {
+		if strings.EqualFold(c, name) {
+			return true
+		}
+	}
+	return false
+}
+
 func (t *TableInfo) ConvertGoQu(f *FieldInfo) string {
-	var goqu string
-	switch f.Field {
-	case "id":
-		goqu = "pk,skipinsert,skipupdate"
-	case "create_time", "update_time":
-		goqu = "skipinsert,skipupdate"
+	var tags []string
+	if f.Key == "PRI" {
+		tags = append(tags, "pk")
+	}
+
+	skipInsert := f.IsAutoIncrement() || f.isGenerated() || isAuditColumn(f.Field)
+	skipUpdate := f.IsAutoIncrement() || f.isGenerated() || isAuditColumn(f.Field) || f.isOnUpdateTimestamp()
+	// a zero time.Time is not empty for goqu, leave CURRENT_TIMESTAMP columns to the database
+	if t.ConvertType(f) == "time.Time" && f.isDefaultTimestamp() {
+		skipInsert = true
+	}
+	if skipInsert {
+		tags = append(tags, "skipinsert")
+	}
+	if skipUpdate {
+		tags = append(tags, "skipupdate")
+	}
+
+	// sql.Null* are never empty, an invalid value is written as NULL already
+	if f.Key != "PRI" && !skipInsert && !strings.Contains(t.FieldType(f), ".Null") {
+		tags = append(tags, "defaultifempty")
+	}
+
+	return strings.Join(tags, ",")
+}
+
+// defaultSoftDeleteColumns mark soft deleted rows unless Config.SoftDeleteColumns is set,
+// the first one the table has is used
+var defaultSoftDeleteColumns = []string{"deleted_at", "is_deleted"}
+
+// softDelete is how a column marks deleted rows, filter and value are go expressions
+// of the generated code
+type softDelete struct {
+	field  string
+	filter string // condition of the rows not deleted
+	value  string // value set by SoftDelete
+}
+
+// softDeleteColumn returns the soft delete column of the table, nil if it has none. A nullable
+// DATETIME/TIMESTAMP is set to CURRENT_TIMESTAMP, a TINYINT/BIT flag to 1 and other
+// integers to UNIX_TIMESTAMP(), rows not deleted have NULL or 0
+func (t *TableInfo) softDeleteColumn() *softDelete {
+	columns := defaultSoftDeleteColumns
+	if config.SoftDeleteColumns != nil {
+		columns = config.SoftDeleteColumns
+	}
+	for _, c := range columns {
+		f := t.Field(c)
+		if f == nil {
+			continue
+		}
+		ident := fmt.Sprintf("goqu.C(\"%s\")", f.Field)
+		switch parseColumnType(f.Type).Name {
+		case "datetime", "timestamp", "date":
+			if !f.IsNullable() {
+				fmt.Printf("soft delete column [%s.%s] must be nullable, skipped\n", t.Name(), f.Field)
+				continue
+			}
+			return &softDelete{field: f.Field, filter: ident + ".IsNull()", value: "goqu.L(\"CURRENT_TIMESTAMP\")"}
+		case "tinyint", "bit", "bool", "boolean":
+			return &softDelete{field: f.Field, filter: ident + ".Eq(0)", value: "1"}
+		case "smallint", "mediumint", "int", "integer", "bigint":
+			return &softDelete{field: f.Field, filter: ident + ".Eq(0)", value: "goqu.L(\"UNIX_TIMESTAMP()\")"}
+		default:
+			fmt.Printf("soft delete column [%s.%s] has unsupported type %s, skipped\n", t.Name(), f.Field, f.Type)
+		}
+	}
+	return nil
+}
+
+// nullTypes maps a plain type to the sql.Null* type able to hold it
+var nullTypes = map[string]string{
+	"string":    "sql.NullString",
+	"int8":      "sql.NullInt32",
+	"int16":     "sql.NullInt32",
+	"int32":     "sql.NullInt32",
+	"int64":     "sql.NullInt64",
+	"uint8":     "sql.NullInt32",
+	"uint16":    "sql.NullInt32",
+	"uint32":    "sql.NullInt64",
+	"float32":   "sql.NullFloat64",
+	"float64":   "sql.NullFloat64",
+	"bool":      "sql.NullBool",
+	"time.Time": "sql.NullTime",
+
+	"decimal.Decimal": "decimal.NullDecimal",
+}
+
+// nilableTypes can hold NULL without a pointer
+var nilableTypes = map[string]bool{
+	"json.RawMessage": true,
+}
+
+// FieldType is the struct field type, it is ConvertType with nullable columns
+// wrapped according to Options.Null
+func (t *TableInfo) FieldType(f *FieldInfo) string {
+	typ := t.ConvertType(f)
+	if !f.IsNullable() || strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "*") || nilableTypes[typ] {
+		return typ
+	}
+	switch options.Null {
+	case NullSQL:
+		if n, ok := nullTypes[typ]; ok {
+			return n
+		}
+		// no sql.Null* can hold it, like uint64
+		return "*" + typ
+	case NullPointer:
+		return "*" + typ
 	default:
-		goqu = "defaultifempty"
+		return typ
+	}
+}
+
+// columnType is a parsed column type like `int(10) unsigned` or `decimal(10,2)`
+type columnType struct {
+	Name     string
+	Args     []string
+	Unsigned bool
+}
+
+func parseColumnType(typ string) *columnType {
+	ct := &columnType{}
+	rest := strings.TrimSpace(typ)
+	if i := strings.Index(rest, "("); i >= 0 {
+		ct.Name = strings.ToLower(strings.TrimSpace(rest[:i]))
+		j := strings.LastIndex(rest, ")")
+		if j < i {
+			j = len(rest)
+		}
+		ct.Args = splitTypeArgs(rest[i+1 : j])
+		if j < len(rest) {
+			rest = rest[j+1:]
+		} else {
+			rest = ""
+		}
+	} else {
+		arr := strings.Fields(rest)
+		if len(arr) > 0 {
+			ct.Name = strings.ToLower(arr[0])
+			rest = strings.Join(arr[1:], " ")
+		}
+	}
+	for _, m := range strings.Fields(strings.ToLower(rest)) {
+		if m == "unsigned" {
+			ct.Unsigned = true
+		}
+	}
+	return ct
+}
+
+// splitTypeArgs splits `10,2` or `'a','b,c'` on commas outside quotes, quotes are kept
+func splitTypeArgs(s string) []string {
+	var args []string
+	var quoted bool
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\'' && quoted && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == '\'':
+			quoted = !quoted
+		case s[i] == ',' && !quoted:
+			args = append(args, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
 	}
+	if start < len(s) {
+		args = append(args, strings.TrimSpace(s[start:]))
+	}
+	return args
+}
 
-	filedType := t.ConvertType(f)
-	if filedType == "time.Time" {
-		goqu = "skipinsert,skipupdate"
+// decimalType returns the go type of DECIMAL columns according to Options.Decimal
+func decimalType() string {
+	switch options.Decimal {
+	case DecimalString:
+		return "string"
+	case DecimalShopspring:
+		return "decimal.Decimal"
+	case DecimalLocal:
+		return "modelutil.Decimal"
+	default:
+		return "float32"
 	}
+}
 
-	return goqu
+// intType returns the signed or unsigned go type of bits width
+func intType(bits string, unsigned bool) string {
+	if unsigned {
+		return "uint" + bits
+	}
+	return "int" + bits
 }
 
 func (t *TableInfo) ConvertType(f *FieldInfo) string {
-	typeArr := strings.Split(f.Type, "(")
-	switch typeArr[0] {
-	case "int":
-		return "int32"
-	case "integer":
-		return "int32"
-	case "mediumint":
-		return "int32"
-	case "bit":
-		return "int32"
-	case "year":
-		return "int32"
-	case "smallint":
-		return "int8"
+	if typ, ok := config.overrideType(t.Name(), f); ok {
+		return typ
+	}
+	ct := parseColumnType(f.Type)
+	switch ct.Name {
 	case "tinyint":
+		if options.TinyIntBool && len(ct.Args) == 1 && ct.Args[0] == "1" {
+			return "bool"
+		}
+		return intType("8", ct.Unsigned)
+	case "bool", "boolean":
+		if options.TinyIntBool {
+			return "bool"
+		}
 		return "int8"
+	case "smallint":
+		return intType("16", ct.Unsigned)
+	case "mediumint", "int", "integer":
+		return intType("32", ct.Unsigned)
 	case "bigint":
-		return "int64"
-	case "decimal":
-		return "float32"
+		return intType("64", ct.Unsigned)
+	case "bit":
+		// the driver returns BIT as raw big-endian bytes, which database/sql cannot scan into an integer
+		return "[]byte"
+	case "year":
+		return "int32"
+	case "decimal", "numeric":
+		return decimalType()
 	case "double":
 		return "float64"
 	case "float":
 		return "float32"
 	case "real":
 		return "float32"
-	case "numeric":
-		return "float32"
-	case "timestamp":
-		return "string"
-	case "datetime":
-		return "string"
-	case "date":
+	case "timestamp", "datetime", "date":
+		if options.Time {
+			return "time.Time"
+		}
 		return "string"
 	case "time":
+		// TIME is a duration up to 838 hours, it does not fit time.Time
+		return "string"
+	case "json":
+		if len(options.JSONType) > 0 {
+			return options.JSONType
+		}
+		return "json.RawMessage"
+	case "enum":
+		return t.EnumType(f)
+	case "set":
+		// SET is returned as the comma separated member list
 		return "string"
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		return "[]byte"
+	case "geometry", "point", "linestring", "polygon", "multipoint", "multilinestring",
+		"multipolygon", "geometrycollection", "geomcollection":
+		// spatial values are returned in MySQL internal format, a SRID followed by WKB
+		return "[]byte"
 	default:
 		return "string"
 	}
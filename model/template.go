@@ -0,0 +1,508 @@
+package mysql
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/golang/protobuf/protoc-gen-go/generator"
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// templateOrder is the order the built-in templates are rendered in, the templates added
+// by -template-dir follow in name order
+var templateOrder = []string{
+	"header.tmpl",
+	"struct.tmpl",
+	"enums.tmpl",
+	"columns.tmpl",
+	"options.tmpl",
+	"get.tmpl",
+	"search.tmpl",
+	"search_after.tmpl",
+	"count.tmpl",
+	"create.tmpl",
+	"upsert.tmpl",
+	"update.tmpl",
+	"delete.tmpl",
+	"soft_delete.tmpl",
+	"lookups.tmpl",
+	"relations.tmpl",
+	"repo.tmpl",
+}
+
+var templates *template.Template
+var templateNames []string
+
+// TemplateData is what every template is executed with, one per table
+type TemplateData struct {
+	Package      string                 // package of the generated file, like user
+	ModelPackage string                 // import path of the model directory, imported as db
+	Table        string                 // table name, like t_user
+	Struct       string                 // struct name, like User
+	Receiver     string                 // variable name of one row, like u
+	Fields       []*TemplateField       // columns in table order
+	PrimaryKey   []*TemplateField       // primary key columns, empty without a primary key
+	Keys         []*TemplateKey         // indexes, primary first, then unique, then the others
+	Lookups      []*TemplateKey         // indexes that get a Get<Struct>By or Search<Struct>By function
+	ForeignKeys  []*TemplateForeignKey  // foreign keys ordered by name
+	Enums        []*TemplateEnum        // ENUM columns
+	Decimal      bool                   // a column uses modelutil.Decimal
+	SoftDelete   *TemplateSoftDelete    // nil without a soft delete column
+	UpsertFields []string               // columns Upsert<Struct> updates by default
+	CursorOrders []*TemplateCursorOrder // orderings Search<Struct>After accepts, empty without a usable index
+	CursorOrder  []string               // default ordering of Search<Struct>After
+	CursorFields []*TemplateField       // columns of the cursor orderings
+	Options      Options                // command line options
+}
+
+// TemplateField is one column
+type TemplateField struct {
+	Column        string  // column name, like user_name
+	Name          string  // struct field name, like UserName
+	JSONName      string  // json key, like userName
+	Param         string  // parameter name, like userName
+	Type          string  // go type in the struct, nullable columns are wrapped following -null
+	BaseType      string  // go type without the NULL wrapping, used by parameters
+	Import        string  // import path Type needs, empty for builtin types
+	BaseImport    string  // import path BaseType needs, registered wherever BaseType is rendered
+	ColumnType    string  // column type as declared, like varchar(64)
+	Comment       string  // column comment
+	Tag           string  // struct tag
+	Default       *string // column default, nil without one
+	Nullable      bool    // accepts NULL
+	Primary       bool    // part of the primary key
+	AutoIncrement bool    // AUTO_INCREMENT
+	Comparable    bool    // gets Where<Name>Eq and Where<Name>In, false for binary and JSON columns
+	Ordered       bool    // gets Where<Name>Between, true for numbers and times
+}
+
+// TemplateKey is one index
+type TemplateKey struct {
+	Name    string           // index name, PRIMARY for the primary key
+	Primary bool             // primary key
+	Unique  bool             // primary or unique key
+	Type    string           // BTREE, HASH, FULLTEXT or SPATIAL
+	Fields  []*TemplateField // key columns in key order
+	By      string           // function name suffix of the lookups, like UserIdOrderId
+}
+
+// TemplateForeignKey is one foreign key, the loaders are only named for a key of one column
+type TemplateForeignKey struct {
+	Name       string           // constraint name
+	Fields     []*TemplateField // key columns in key order
+	RefTable   string           // referenced table, like t_user
+	RefColumns []string         // referenced columns in key order
+	OnUpdate   string           // RESTRICT, CASCADE, SET NULL, NO ACTION or SET DEFAULT
+	OnDelete   string           // like OnUpdate
+	List       string           // function returning the rows by key values, like ListOrdersByUserId
+	Load       string           // function returning the referenced rows, like LoadUserForOrders, empty without one
+	RefStruct  string           // struct of the referenced table, like User
+	RefPackage string           // package of the referenced table, like user, empty when the table references itself
+	RefImport  string           // import path of RefPackage
+	RefField   *TemplateField   // referenced column
+}
+
+// TemplateEnum is one ENUM column with its named type
+type TemplateEnum struct {
+	Type   string // named string type, like UserStatus
+	Field  *TemplateField
+	Values []*TemplateEnumValue
+}
+
+// TemplateEnumValue is one value of an ENUM column
+type TemplateEnumValue struct {
+	Name  string // constant name, like UserStatusActive
+	Value string // value as stored
+}
+
+// TemplateSoftDelete is the soft delete column of a table
+type TemplateSoftDelete struct {
+	Column string // column name
+	Filter string // go expression selecting the rows not deleted
+	Value  string // go expression of the value marking a row deleted
+}
+
+// TemplateCursorOrder is one ordering of the keyset pagination
+type TemplateCursorOrder struct {
+	Key     string   // orderCols joined by commas
+	Columns []string // ordering columns, the primary key completes a non unique index
+}
+
+// LoadTemplates parses the built-in templates, then the .tmpl files of dir when it is not empty.
+// A file named like a built-in one replaces it, an empty file drops it, the others are added
+func LoadTemplates(dir string) error {
+	t, err := template.New("").Funcs(templateFuncs(nil)).ParseFS(builtinTemplates, "templates/*.tmpl")
+	if err != nil {
+		return err
+	}
+	names := append([]string(nil), templateOrder...)
+	if len(dir) > 0 {
+		files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+		if err != nil {
+			return err
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			b, err := ioutil.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			name := filepath.Base(file)
+			// text/template keeps the old body when a template is redefined empty
+			if len(strings.TrimSpace(string(b))) == 0 {
+				for i, n := range names {
+					if n == name {
+						names = append(names[:i], names[i+1:]...)
+						break
+					}
+				}
+				continue
+			}
+			if _, err := t.New(name).Parse(string(b)); err != nil {
+				return err
+			}
+			if !contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	templates, templateNames = t, names
+	return nil
+}
+
+// templateFuncs are the functions templates can call, import adds an import path, or
+// "alias path", to the generated file and renders nothing
+func templateFuncs(g *Generate) template.FuncMap {
+	return template.FuncMap{
+		"import": func(path string) string {
+			if g != nil {
+				g.addImport(path)
+			}
+			return ""
+		},
+		"join":  strings.Join,
+		"quote": strconv.Quote,
+	}
+}
+
+// render executes the templates with the data of the table into g.buf
+func (g *Generate) render() error {
+	if templates == nil {
+		if err := LoadTemplates(""); err != nil {
+			return err
+		}
+	}
+	t, err := templates.Clone()
+	if err != nil {
+		return err
+	}
+	t.Funcs(templateFuncs(g))
+	data := g.templateData()
+	for _, name := range templateNames {
+		var b bytes.Buffer
+		if err := t.ExecuteTemplate(&b, name, data); err != nil {
+			return err
+		}
+		g.buf.Write(b.Bytes())
+		g.buf.WriteString("\n")
+	}
+	return nil
+}
+
+// templateData collects what the templates need from the table
+func (g *Generate) templateData() *TemplateData {
+	t := g.tableInfo
+	d := &TemplateData{
+		Package:      g.getLowerName(),
+		ModelPackage: Package,
+		Table:        g.dbInfo.selectTableName,
+		Struct:       generator.CamelCase(g.structName),
+		Options:      *options,
+	}
+	d.Receiver = strings.ToLower(d.Struct[0:1])
+
+	fields := make(map[string]*TemplateField)
+	for _, f := range t.Fields {
+		tf := templateField(t, f)
+		fields[f.Field] = tf
+		d.Fields = append(d.Fields, tf)
+		if tf.Primary {
+			d.PrimaryKey = append(d.PrimaryKey, tf)
+		}
+		if options.Decimal == DecimalLocal && strings.TrimLeft(tf.Type, "*") == "modelutil.Decimal" {
+			d.Decimal = true
+		}
+		if e := g.templateEnum(f); e != nil {
+			e.Field = tf
+			d.Enums = append(d.Enums, e)
+		}
+	}
+
+	done := make(map[string]bool)
+	for _, idx := range t.Indexes {
+		k := &TemplateKey{Name: idx.Name, Primary: idx.Primary, Unique: idx.Unique, Type: idx.Type}
+		for _, c := range idx.Columns {
+			f, ok := fields[c]
+			if !ok {
+				k.By = ""
+				break
+			}
+			k.Fields = append(k.Fields, f)
+			k.By += f.Name
+		}
+		d.Keys = append(d.Keys, k)
+		// a functional key part or a key already covered by a unique one
+		if len(k.By) == 0 || done[k.By] || idx.Type == "FULLTEXT" || idx.Type == "SPATIAL" {
+			continue
+		}
+		done[k.By] = true
+		d.Lookups = append(d.Lookups, k)
+	}
+
+	d.ForeignKeys = g.templateForeignKeys(d.Struct, fields)
+
+	if sd := g.softDelete; sd != nil {
+		d.SoftDelete = &TemplateSoftDelete{Column: sd.field, Filter: sd.filter, Value: sd.value}
+	}
+	d.UpsertFields = g.upsertFields()
+
+	def, orders := g.cursorOrders()
+	keys := make([]string, 0, len(orders))
+	for k := range orders {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	cols := make(map[string]bool)
+	for _, k := range keys {
+		d.CursorOrders = append(d.CursorOrders, &TemplateCursorOrder{Key: k, Columns: orders[k]})
+		for _, c := range orders[k] {
+			cols[c] = true
+		}
+	}
+	d.CursorOrder = def
+	for _, f := range d.Fields {
+		if cols[f.Column] {
+			d.CursorFields = append(d.CursorFields, f)
+		}
+	}
+	return d
+}
+
+func templateField(t *TableInfo, f *FieldInfo) *TemplateField {
+	name := generator.CamelCase(f.Field)
+	tf := &TemplateField{
+		Column:        f.Field,
+		Name:          name,
+		JSONName:      strings.ToLower(name[0:1]) + name[1:],
+		Param:         paramName(f.Field),
+		Type:          t.FieldType(f),
+		BaseType:      t.ConvertType(f),
+		ColumnType:    f.Type,
+		Comment:       strings.Trim(f.Comment, " "),
+		Default:       f.Default,
+		Nullable:      f.IsNullable(),
+		Primary:       f.Key == "PRI",
+		AutoIncrement: f.IsAutoIncrement(),
+	}
+	tf.Import = importOfType(tf.Type)
+	tf.BaseImport = importOfType(tf.BaseType)
+	tf.Tag = fmt.Sprintf("db:\"%s\" json:\"%s,omitempty\"", f.Field, tf.JSONName)
+	if goqu := t.ConvertGoQu(f); len(goqu) > 0 {
+		tf.Tag += fmt.Sprintf(" goqu:\"%s\"", goqu)
+	}
+	tf.Comparable = !strings.HasPrefix(tf.BaseType, "[]") && tf.BaseType != "json.RawMessage" &&
+		parseColumnType(f.Type).Name != "json"
+	tf.Ordered = tf.Comparable && isOrderedType(f, tf.BaseType)
+	return tf
+}
+
+// Null is the go condition under which the field of v is NULL, empty when the field cannot hold NULL
+func (f *TemplateField) Null(v string) string {
+	switch {
+	case f.Type == f.BaseType:
+		return ""
+	case strings.HasPrefix(f.Type, "*"):
+		return v + "." + f.Name + " == nil"
+	default:
+		return "!" + v + "." + f.Name + ".Valid"
+	}
+}
+
+// Value is the go expression of the field of v as BaseType, to be used when Null does not hold
+func (f *TemplateField) Value(v string) string {
+	switch {
+	case f.Type == f.BaseType:
+		return v + "." + f.Name
+	case strings.HasPrefix(f.Type, "*"):
+		return "*" + v + "." + f.Name
+	}
+	// like sql.NullInt32 holding a uint8 in its Int32 field
+	inner := f.Type[strings.Index(f.Type, ".Null")+len(".Null"):]
+	value := v + "." + f.Name + "." + inner
+	switch inner {
+	case "Time":
+		inner = "time.Time"
+	case "Decimal":
+		inner = "decimal.Decimal"
+	default:
+		inner = strings.ToLower(inner)
+	}
+	if inner != f.BaseType {
+		return f.BaseType + "(" + value + ")"
+	}
+	return value
+}
+
+// templateForeignKeys names the loaders of the foreign keys. A referenced table gets Load only when it
+// is in the source, its package is generated and it does not reference the table back, which would make
+// the packages import each other
+func (g *Generate) templateForeignKeys(structName string, fields map[string]*TemplateField) []*TemplateForeignKey {
+	t := g.tableInfo
+	refs := make(map[string]int)
+	for _, fk := range t.ForeignKeys {
+		refs[strings.ToLower(fk.RefTable)]++
+	}
+	var keys []*TemplateForeignKey
+	for _, fk := range t.ForeignKeys {
+		k := &TemplateForeignKey{Name: fk.Name, RefTable: fk.RefTable, RefColumns: fk.RefColumns, OnUpdate: fk.OnUpdate, OnDelete: fk.OnDelete}
+		for _, c := range fk.Columns {
+			if f, ok := fields[c]; ok {
+				k.Fields = append(k.Fields, f)
+			}
+		}
+		keys = append(keys, k)
+		if len(fk.Columns) != 1 || len(k.Fields) != 1 || !k.Fields[0].Comparable {
+			continue
+		}
+		field := k.Fields[0]
+		k.List = "List" + structName + "sBy" + field.Name
+
+		self := len(fk.RefSchema) == 0 && strings.EqualFold(fk.RefTable, t.Name())
+		if len(fk.RefSchema) > 0 {
+			continue
+		}
+		ref := t
+		if !self {
+			ref = NewTableInfo(t.source).TableProfit(sameSchema(t.TableName, fk.RefTable))
+			if reaches(t.source, ref.TableName, t.Name(), make(map[string]bool)) {
+				fmt.Printf("table [%s] and [%s] reference each other, the loader of %s skipped\n", t.Name(), fk.RefTable, fk.Name)
+				continue
+			}
+		}
+		refField := ref.Field(fk.RefColumns[0])
+		if refField == nil {
+			continue
+		}
+		k.RefField = templateField(ref, refField)
+		k.RefStruct = ref.StructName()
+		by := ""
+		if self || refs[strings.ToLower(fk.RefTable)] > 1 {
+			by = "By" + field.Name
+		}
+		if !self {
+			pkg := packageName(baseName(ref.Name()))
+			if !generatedPackage(ref.Name(), pkg) {
+				fmt.Printf("table [%s] is not generated, the loader of %s skipped, generate it too or run without -t\n", fk.RefTable, fk.Name)
+				continue
+			}
+			k.RefPackage = pkg
+			k.RefImport = Package + "/" + pkg
+		}
+		k.Load = "Load" + k.RefStruct + by + "For" + structName + "s"
+	}
+	return keys
+}
+
+// generatedPackage reports whether the package of table is written by this run, which is every table
+// without -t, or by an earlier one into the current directory
+func generatedPackage(table, pkg string) bool {
+	if conInfo == nil || len(conInfo.T) == 0 || strings.EqualFold(baseName(conInfo.T), baseName(table)) {
+		return true
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(dir, pkg, pkg+".go"))
+	return err == nil
+}
+
+// sameSchema qualifies name with the database table is qualified with
+func sameSchema(table, name string) string {
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		return table[:i+1] + name
+	}
+	return name
+}
+
+// reaches reports whether table references target through its foreign keys, directly or not
+func reaches(source SchemaSource, table, target string, seen map[string]bool) bool {
+	key := strings.ToLower(table)
+	if seen[key] {
+		return false
+	}
+	seen[key] = true
+	infos, err := source.ForeignKeys(table)
+	if err != nil {
+		return false
+	}
+	for _, info := range infos {
+		if len(info.RefSchema) > 0 {
+			continue
+		}
+		if strings.EqualFold(info.RefTable, target) || reaches(source, sameSchema(table, info.RefTable), target, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// templateEnum names the type and the constants of an ENUM column, nil for other columns
+func (g *Generate) templateEnum(f *FieldInfo) *TemplateEnum {
+	values := f.EnumValues()
+	if values == nil {
+		return nil
+	}
+	e := &TemplateEnum{Type: g.tableInfo.EnumType(f)}
+	used := make(map[string]bool)
+	for _, v := range values {
+		name := e.Type + enumConstName(v)
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s%s%d", e.Type, enumConstName(v), n)
+		}
+		used[name] = true
+		e.Values = append(e.Values, &TemplateEnumValue{Name: name, Value: v})
+	}
+	return e
+}
+
+// upsertFields are the inserted columns outside the primary and unique keys
+func (g *Generate) upsertFields() []string {
+	keyColumns := make(map[string]bool)
+	for _, idx := range g.tableInfo.Indexes {
+		if idx.Unique {
+			for _, c := range idx.Columns {
+				keyColumns[c] = true
+			}
+		}
+	}
+	var fields []string
+	for _, f := range g.tableInfo.Fields {
+		tag := g.tableInfo.ConvertGoQu(f)
+		if keyColumns[f.Field] || strings.Contains(tag, "skipinsert") || strings.Contains(tag, "skipupdate") {
+			continue
+		}
+		fields = append(fields, f.Field)
+	}
+	return fields
+}
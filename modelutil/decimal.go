@@ -0,0 +1,41 @@
+package modelutil
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+)
+
+// Decimal keeps a DECIMAL column in its exact string form, the models of -decimal local use it
+type Decimal string
+
+// Scan implements sql.Scanner
+func (d *Decimal) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = ""
+	case []byte:
+		*d = Decimal(v)
+	case string:
+		*d = Decimal(v)
+	case int64:
+		*d = Decimal(strconv.FormatInt(v, 10))
+	case float64:
+		*d = Decimal(strconv.FormatFloat(v, 'f', -1, 64))
+	default:
+		return fmt.Errorf("decimal: cannot scan %T", src)
+	}
+	return nil
+}
+
+// Value implements driver.Valuer, an empty Decimal is written as NULL
+func (d Decimal) Value() (driver.Value, error) {
+	if len(d) == 0 {
+		return nil, nil
+	}
+	return string(d), nil
+}
+
+func (d Decimal) String() string {
+	return string(d)
+}
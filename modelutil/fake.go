@@ -0,0 +1,193 @@
+package modelutil
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// Match reports whether a row matches exps the way MySQL would, value returns the column
+// of the row. The fake repositories use it, so exps may only be nil, a map, exp.Record or
+// goqu.Ex of column values, a slice value matches any of its elements and nil matches NULL.
+// Other conditions, like expressions or goqu.Op, return a *ConditionTypeError
+func Match(exps interface{}, value func(column string) (interface{}, bool)) (bool, error) {
+	var m map[string]interface{}
+	switch e := exps.(type) {
+	case nil:
+		return true, nil
+	case map[string]interface{}:
+		m = e
+	case exp.Record:
+		m = e
+	case exp.Ex:
+		m = e
+	default:
+		return false, &ConditionTypeError{Value: exps}
+	}
+	for k, want := range m {
+		if _, ok := want.(exp.Op); ok {
+			return false, &ConditionTypeError{Value: want}
+		}
+		got, ok := value(k)
+		if !ok {
+			return false, fmt.Errorf("modelutil: unknown column %q", k)
+		}
+		if !matchValue(got, want) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func matchValue(got, want interface{}) bool {
+	w := reflect.ValueOf(want)
+	if want != nil && w.Kind() == reflect.Slice && w.Type().Elem().Kind() != reflect.Uint8 {
+		for i := 0; i < w.Len(); i++ {
+			if Equal(got, w.Index(i).Interface()) {
+				return true
+			}
+		}
+		return false
+	}
+	return Equal(got, want)
+}
+
+// Equal compares a column value with a condition value, pointers and driver.Valuer
+// like sql.NullString are unwrapped, numbers compare by value and named types by their kind
+func Equal(a, b interface{}) bool {
+	a, b = plain(a), plain(b)
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if t, ok := a.(time.Time); ok {
+		u, ok := b.(time.Time)
+		return ok && t.Equal(u)
+	}
+	if x, ok := a.([]byte); ok {
+		switch y := b.(type) {
+		case []byte:
+			return bytes.Equal(x, y)
+		case string:
+			return string(x) == y
+		}
+		return false
+	}
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch {
+	case isInt(va) && isInt(vb):
+		return toInt(va) == toInt(vb)
+	case isNumber(va) && isNumber(vb):
+		return toFloat(va) == toFloat(vb)
+	case va.Kind() == reflect.String && vb.Kind() == reflect.String:
+		return va.String() == vb.String()
+	case va.Kind() == reflect.Bool && vb.Kind() == reflect.Bool:
+		return va.Bool() == vb.Bool()
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// plain unwraps pointers and driver.Valuer, NULL becomes nil
+func plain(v interface{}) interface{} {
+	for v != nil {
+		if dv, ok := v.(driver.Valuer); ok {
+			if _, isTime := v.(time.Time); !isTime {
+				val, err := dv.Value()
+				if err != nil {
+					return v
+				}
+				v = val
+				if _, again := v.(driver.Valuer); again {
+					return v
+				}
+				continue
+			}
+		}
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Ptr {
+			return v
+		}
+		if rv.IsNil() {
+			return nil
+		}
+		v = rv.Elem().Interface()
+	}
+	return nil
+}
+
+func isInt(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func isNumber(v reflect.Value) bool {
+	return isInt(v) || v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64
+}
+
+// toInt compares unsigned values above the int64 range as their two's complement, which
+// keeps them apart from any other unsigned value
+func toInt(v reflect.Value) int64 {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	}
+	return v.Int()
+}
+
+func toFloat(v reflect.Value) float64 {
+	switch {
+	case v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64:
+		return v.Float()
+	case v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uint64:
+		return float64(v.Uint())
+	}
+	return float64(v.Int())
+}
+
+// Assign sets the field ptr points to from a value of the Update data, nil clears it,
+// sql.Scanner fields scan the value and other values are converted to the field type
+func Assign(ptr interface{}, value interface{}) error {
+	dst := reflect.ValueOf(ptr)
+	if dst.Kind() != reflect.Ptr || dst.IsNil() {
+		return fmt.Errorf("modelutil: assign to %T", ptr)
+	}
+	if s, ok := ptr.(sql.Scanner); ok {
+		return s.Scan(plain(value))
+	}
+	field := dst.Elem()
+	value = plain(value)
+	if value == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	if field.Kind() == reflect.Ptr {
+		elem := reflect.New(field.Type().Elem())
+		if err := Assign(elem.Interface(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+	v := reflect.ValueOf(value)
+	// int to string converts to a rune in go, which is never what an Update means
+	if field.Kind() == reflect.String && v.Kind() != reflect.String {
+		if b, ok := value.([]byte); ok {
+			field.SetString(string(b))
+			return nil
+		}
+		return fmt.Errorf("modelutil: cannot assign %T to %s", value, field.Type())
+	}
+	if !v.Type().ConvertibleTo(field.Type()) {
+		return fmt.Errorf("modelutil: cannot assign %T to %s", value, field.Type())
+	}
+	field.Set(v.Convert(field.Type()))
+	return nil
+}
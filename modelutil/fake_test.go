@@ -0,0 +1,144 @@
+package modelutil
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+func TestEqual(t *testing.T) {
+	n := int64(3)
+	s := "a"
+	var nilInt *int64
+	at := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	cases := []struct {
+		name string
+		a, b interface{}
+		want bool
+	}{
+		{"nil", nil, nil, true},
+		{"nil and value", nil, 0, false},
+		{"nil pointer", nilInt, nil, true},
+		{"pointer", &n, 3, true},
+		{"pointer differs", &n, 4, false},
+		{"pointer string", &s, "a", true},
+		{"int widths", int8(3), uint64(3), true},
+		{"int and float", 3, 3.0, true},
+		{"named string", Decimal("a"), "a", true},
+		{"bool", true, false, false},
+		{"bytes and string", []byte("ab"), "ab", true},
+		{"time", at, at.In(time.FixedZone("CST", 8*3600)), true},
+		{"time differs", at, at.Add(time.Second), false},
+		{"time and string", at, "2021-03-04 05:06:07", false},
+		{"null string", sql.NullString{}, nil, true},
+		{"valid null string", sql.NullString{String: "a", Valid: true}, "a", true},
+		{"null time", sql.NullTime{Time: at, Valid: true}, at, true},
+		{"decimal", Decimal("1.50"), "1.50", true},
+		{"decimal differs", Decimal("1.50"), "1.5", false},
+		{"empty decimal", Decimal(""), nil, true},
+	}
+	for _, c := range cases {
+		if got := Equal(c.a, c.b); got != c.want {
+			t.Errorf("%s: Equal(%#v, %#v) = %v, want %v", c.name, c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	age := int64(20)
+	row := map[string]interface{}{
+		"id":         int64(1),
+		"age":        &age,
+		"nick":       (*string)(nil),
+		"created_at": time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC),
+	}
+	value := func(column string) (interface{}, bool) {
+		v, ok := row[column]
+		return v, ok
+	}
+	cases := []struct {
+		name    string
+		exps    interface{}
+		want    bool
+		wantErr bool
+	}{
+		{"nil", nil, true, false},
+		{"map", map[string]interface{}{"id": 1}, true, false},
+		{"record pointer", goqu.Record{"age": 20}, true, false},
+		{"null", goqu.Ex{"nick": nil}, true, false},
+		{"not null", goqu.Ex{"age": nil}, false, false},
+		{"in", goqu.Ex{"id": []int{3, 1}}, true, false},
+		{"not in", goqu.Ex{"id": []int{3, 4}}, false, false},
+		{"time", goqu.Ex{"created_at": time.Date(2021, 3, 4, 8, 0, 0, 0, time.FixedZone("CST", 8*3600))}, true, false},
+		{"all keys", goqu.Ex{"id": 1, "age": 21}, false, false},
+		{"unknown column", goqu.Ex{"name": "a"}, false, true},
+		{"operator", goqu.Ex{"age": goqu.Op{"gt": 18}}, false, true},
+		{"expression", goqu.C("age").Gt(18), false, true},
+	}
+	for _, c := range cases {
+		got, err := Match(c.exps, value)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: err = %v, want error %v", c.name, err, c.wantErr)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s: Match = %v, want %v", c.name, got, c.want)
+		}
+	}
+	if _, err := Match(goqu.C("age").Gt(18), value); err != nil {
+		if _, ok := err.(*ConditionTypeError); !ok {
+			t.Errorf("expression: %T, want *ConditionTypeError", err)
+		}
+	}
+}
+
+func TestAssign(t *testing.T) {
+	var (
+		i      int32
+		s      string
+		p      *int64
+		d      Decimal
+		ns     sql.NullString
+		at     time.Time
+		nt     sql.NullTime
+		second = time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	)
+	n := int64(9)
+	cases := []struct {
+		name    string
+		ptr     interface{}
+		value   interface{}
+		check   func() bool
+		wantErr bool
+	}{
+		{"int", &i, int64(5), func() bool { return i == 5 }, false},
+		{"int from pointer", &i, &n, func() bool { return i == 9 }, false},
+		{"nil clears", &i, nil, func() bool { return i == 0 }, false},
+		{"string", &s, "a", func() bool { return s == "a" }, false},
+		{"string from bytes", &s, []byte("b"), func() bool { return s == "b" }, false},
+		{"int to string", &s, 65, nil, true},
+		{"pointer", &p, 7, func() bool { return p != nil && *p == 7 }, false},
+		{"pointer nil", &p, nil, func() bool { return p == nil }, false},
+		{"decimal", &d, "1.50", func() bool { return d == "1.50" }, false},
+		{"decimal from float", &d, 2.5, func() bool { return d == "2.5" }, false},
+		{"decimal nil", &d, nil, func() bool { return d == "" }, false},
+		{"null string", &ns, "x", func() bool { return ns.Valid && ns.String == "x" }, false},
+		{"null string nil", &ns, nil, func() bool { return !ns.Valid }, false},
+		{"time", &at, second, func() bool { return at.Equal(second) }, false},
+		{"null time", &nt, second, func() bool { return nt.Valid && nt.Time.Equal(second) }, false},
+		{"time from string", &at, "2021-03-04", nil, true},
+		{"not a pointer", i, 1, nil, true},
+	}
+	for _, c := range cases {
+		err := Assign(c.ptr, c.value)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: err = %v, want error %v", c.name, err, c.wantErr)
+			continue
+		}
+		if c.check != nil && !c.check() {
+			t.Errorf("%s: field not set from %#v", c.name, c.value)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Package modelutil holds the helpers the generated models call, so the condition and
+// column handling is written once instead of in every generated function
+package modelutil
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// ConditionTypeError is returned for an exps value BuildConditions does not support
+type ConditionTypeError struct {
+	Value interface{}
+}
+
+func (e *ConditionTypeError) Error() string {
+	return fmt.Sprintf("modelutil: unsupported condition type %T", e.Value)
+}
+
+// Option changes how a generated write function runs
+type Option int
+
+const (
+	// AllowFullTable lets Update run without conditions, changing every row
+	AllowFullTable Option = iota + 1
+)
+
+// Lock is the locking read of QueryOptions
+type Lock int
+
+const (
+	LockNone Lock = iota
+	// LockForUpdate appends FOR UPDATE
+	LockForUpdate
+	// LockInShareMode appends LOCK IN SHARE MODE, which MySQL 5.7 and 8 both accept
+	LockInShareMode
+)
+
+// OrderBy orders by Column, descending when Desc is set
+type OrderBy struct {
+	Column string
+	Desc   bool
+}
+
+// QueryOptions are the optional clauses of the generated read functions, nil reads without them.
+// GroupBy needs the selected columns to depend on it under ONLY_FULL_GROUP_BY.
+// A lock reads from the primary, set Tx to hold it until the transaction ends
+type QueryOptions struct {
+	OrderBy []OrderBy
+	GroupBy []string
+	Lock    Lock
+	Tx      *goqu.TxDatabase
+}
+
+// HasOption reports whether o is in options
+func HasOption(options []Option, o Option) bool {
+	for _, e := range options {
+		if e == o {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildConditions turns exps into an expression list, a map or exp.Record becomes one
+// equality per key joined by AND, IS NULL for nil and nil pointers. goqu.Ex and goqu.ExOr are
+// expanded and any other exp.Expression is used as it is. nil is no condition, other types
+// return a *ConditionTypeError
+func BuildConditions(exps interface{}) (exp.ExpressionList, error) {
+	conditions := exp.NewExpressionList(exp.AndType)
+	switch e := exps.(type) {
+	case nil:
+	case map[string]interface{}:
+		for k, v := range e {
+			conditions = conditions.Append(goqu.I(k).Eq(nilPointer(v)))
+		}
+	case exp.Record:
+		for k, v := range e {
+			conditions = conditions.Append(goqu.I(k).Eq(nilPointer(v)))
+		}
+	case exp.Ex:
+		return e.ToExpressions()
+	case exp.ExOr:
+		return e.ToExpressions()
+	case exp.ExpressionList:
+		if !isEmpty(e) {
+			conditions = e
+		}
+	case exp.Expression:
+		if !isEmpty(e) {
+			conditions = conditions.Append(e)
+		}
+	default:
+		return nil, &ConditionTypeError{Value: exps}
+	}
+	return conditions, nil
+}
+
+// nilPointer turns a nil pointer into nil, so a NULL field of a pointer model matches IS NULL
+// instead of = NULL, which matches nothing
+func nilPointer(v interface{}) interface{} {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil
+	}
+	return v
+}
+
+// isEmpty reports whether e renders no condition, like an empty goqu.Ex or a list of empty lists
+func isEmpty(e exp.Expression) bool {
+	switch v := e.(type) {
+	case exp.Ex:
+		return v.IsEmpty()
+	case exp.ExOr:
+		return v.IsEmpty()
+	case exp.ExpressionList:
+		for _, c := range v.Expressions() {
+			if !isEmpty(c) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// SelectColumns returns the columns to select, the ones in includeFields when it is not
+// empty, otherwise the ones not in excludeFields. columns keeps its order
+func SelectColumns(columns []interface{}, includeFields, excludeFields []string) []interface{} {
+	if len(includeFields) == 0 && len(excludeFields) == 0 {
+		return columns
+	}
+	fields := includeFields
+	if len(fields) == 0 {
+		fields = excludeFields
+	}
+	m := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		m[f] = struct{}{}
+	}
+	include := len(includeFields) > 0
+	cols := make([]interface{}, 0, len(columns))
+	for _, c := range columns {
+		if _, ok := m[c.(string)]; ok == include {
+			cols = append(cols, c)
+		}
+	}
+	return cols
+}
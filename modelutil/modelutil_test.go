@@ -0,0 +1,80 @@
+package modelutil
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	_ "github.com/doug-martin/goqu/v9/dialect/mysql"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+func TestBuildConditions(t *testing.T) {
+	id := int64(7)
+	at := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	cases := []struct {
+		name string
+		exps interface{}
+		sql  string
+		args []interface{}
+	}{
+		{"nil", nil, "SELECT * FROM `t`", []interface{}{}},
+		{"map", map[string]interface{}{"id": 1}, "SELECT * FROM `t` WHERE (`id` = ?)", []interface{}{int64(1)}},
+		{"map nil value", map[string]interface{}{"deleted_at": nil}, "SELECT * FROM `t` WHERE (`deleted_at` IS NULL)", []interface{}{}},
+		{"record pointer", goqu.Record{"id": &id}, "SELECT * FROM `t` WHERE (`id` = ?)", []interface{}{int64(7)}},
+		{"record nil pointer", goqu.Record{"id": (*int64)(nil)}, "SELECT * FROM `t` WHERE (`id` IS NULL)", []interface{}{}},
+		{"record time", goqu.Record{"created_at": at}, "SELECT * FROM `t` WHERE (`created_at` = ?)", []interface{}{at}},
+		{"record decimal", goqu.Record{"amount": Decimal("1.50")}, "SELECT * FROM `t` WHERE (`amount` = ?)", []interface{}{"1.50"}},
+		{"ex in", goqu.Ex{"id": []int{1, 2}}, "SELECT * FROM `t` WHERE (`id` IN (?, ?))", []interface{}{int64(1), int64(2)}},
+		{"empty ex", goqu.Ex{}, "SELECT * FROM `t`", []interface{}{}},
+		{"expression", goqu.C("age").Gt(18), "SELECT * FROM `t` WHERE (`age` > ?)", []interface{}{int64(18)}},
+		{"empty lists", exp.NewExpressionList(exp.AndType, goqu.Ex{}, exp.NewExpressionList(exp.OrType)), "SELECT * FROM `t`", []interface{}{}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			conditions, err := BuildConditions(c.exps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sql, args, err := goqu.Dialect("mysql").From("t").Prepared(true).Where(conditions).ToSQL()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != c.sql {
+				t.Errorf("sql = %s, want %s", sql, c.sql)
+			}
+			if !reflect.DeepEqual(args, c.args) {
+				t.Errorf("args = %#v, want %#v", args, c.args)
+			}
+		})
+	}
+}
+
+func TestBuildConditionsUnsupported(t *testing.T) {
+	for _, exps := range []interface{}{"id = 1", 1, []string{"id"}} {
+		if _, err := BuildConditions(exps); err == nil {
+			t.Errorf("%#v: no error", exps)
+		} else if _, ok := err.(*ConditionTypeError); !ok {
+			t.Errorf("%#v: %T, want *ConditionTypeError", exps, err)
+		}
+	}
+}
+
+func TestSelectColumns(t *testing.T) {
+	columns := []interface{}{"id", "name", "age"}
+	cases := []struct {
+		include, exclude []string
+		want             []interface{}
+	}{
+		{nil, nil, columns},
+		{[]string{"age", "id"}, nil, []interface{}{"id", "age"}},
+		{nil, []string{"name"}, []interface{}{"id", "age"}},
+		{[]string{"id"}, []string{"id"}, []interface{}{"id"}},
+	}
+	for _, c := range cases {
+		if got := SelectColumns(columns, c.include, c.exclude); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("SelectColumns(%v, %v) = %v, want %v", c.include, c.exclude, got, c.want)
+		}
+	}
+}